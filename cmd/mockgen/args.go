@@ -3,17 +3,31 @@ package main
 import (
 	"errors"
 	"strings"
+
+	"github.com/squizzling/mockgen/internal/generator"
+	"github.com/squizzling/mockgen/pkg/mockgen"
 )
 
 type packageNameDef string
 
 type Opts struct {
-	Chdir         string             `short:"C" long:"chdir" description:"Directory to run from"`
-	Module        string             `short:"m" long:"module"`
-	OutputFile    string             `short:"f" long:"file"    required:"true"`
-	OutputPackage string             `short:"p" long:"output-package" required:"true"`
-	Input         func(string) error `short:"i" long:"input" description:"Format: <importpath>:<interface>[=struct][,<interface>[=struct]]..."`
-	pkgs          map[string]map[string]string
+	Chdir                 string             `short:"C" long:"chdir" description:"Directory to run from"`
+	Module                string             `short:"m" long:"module"`
+	ConfigFile            string             `short:"c" long:"config" description:"YAML or JSON file describing the mocks to generate, used instead of -f, -p, and -i"`
+	OutputFile            string             `short:"f" long:"file" description:"File to write the mocks to, the mocks are written to stdout if it is - or omitted"`
+	OutputPackage         string             `short:"p" long:"output-package"`
+	Layout                string             `long:"layout" choice:"file" choice:"package" choice:"interface" description:"Write every mock to --file, or one file per source package or per interface to --output-dir"`
+	OutputDir             string             `short:"o" long:"output-dir" description:"Directory to write the files to with --layout package or interface"`
+	FileTemplate          string             `long:"file-template" description:"Template naming each file with --layout package or interface, such as mock_{{.Iface | snake}}.go. Mocks given the same name share a file"`
+	Input                 func(string) error `short:"i" long:"input" description:"Format: <importpath>:<interface>[=struct][,<interface>[=struct]]... Generic interfaces may be instantiated with <interface>[<type>,...]=struct. Every exported interface is matched by * or /<regex>/, and the struct is a template such as Fake{{.Name}}"`
+	Check                 bool               `long:"check" description:"Compare the generated mocks to the existing files and exit with an error if they differ, without writing anything"`
+	NoInterfaceAssertions bool               `long:"no-interface-assertions" description:"Do not generate var _ Iface = (*MockIface)(nil) to check each mock implements its interface"`
+	Expectations          bool               `short:"e" long:"expectations" description:"Generate typed expectation builders (ExpectX().Return().Times())"`
+	Spy                   bool               `long:"spy" description:"Generate an Impl field which calls are forwarded to when they are not handled by an Fn field or expectation"`
+	Backend               string             `long:"backend" choice:"testify" choice:"testing" description:"Fail tests with github.com/stretchr/testify, or with only testing.TB so the mocks have no dependencies"`
+	Fatal                 bool               `long:"fatal" description:"Stop the test immediately when a mock is called with no Fn field, expectation, or Impl to handle it"`
+	Strictness            string             `long:"strictness" choice:"strict" choice:"loose-zero" choice:"loose-with-log" description:"How calls with no Fn field, expectation, or Impl are handled by default: fail the test, return zero values, or return zero values and log the call. Overridden by the Strictness field of a mock"`
+	pkgs                  map[string]map[string]string
 }
 
 func parseInput(opts *Opts) func(string) error {
@@ -31,14 +45,84 @@ func parseInput(opts *Opts) func(string) error {
 		if _, ok := opts.pkgs[pkgPart]; !ok {
 			opts.pkgs[pkgPart] = make(map[string]string)
 		}
-		for _, ifaceDefs := range strings.Split(interfacePart, ",") {
+		for _, ifaceDefs := range generator.SplitTopLevel(interfacePart, ',') {
 			parts = strings.SplitN(ifaceDefs, "=", 2)
-			opts.pkgs[pkgPart][parts[0]] = parts[len(parts)-1] // Use the last, which will either be the first or the second
+			if len(parts) == 2 {
+				opts.pkgs[pkgPart][parts[0]] = parts[1]
+			} else {
+				opts.pkgs[pkgPart][parts[0]] = "" // Use the default name
+			}
 		}
 		return nil
 	}
 }
 
 func (o *Opts) Validate() []string {
-	return nil
+	if o.ConfigFile != "" {
+		if o.OutputFile != "" || o.OutputPackage != "" || o.pkgs != nil || o.Layout != "" || o.OutputDir != "" || o.FileTemplate != "" {
+			return []string{"--config can not be combined with --file, --output-package, --input, --layout, --output-dir, or --file-template"}
+		}
+		return nil
+	}
+
+	var errs []string
+	if o.Layout == "" || o.Layout == mockgen.LayoutFile {
+		if o.Check && (o.OutputFile == "" || o.OutputFile == "-") {
+			errs = append(errs, "--check requires the flag `-f, --file'")
+		}
+	} else if o.OutputDir == "" {
+		errs = append(errs, "the flag `-o, --output-dir' is required with --layout "+o.Layout)
+	}
+	if o.OutputPackage == "" {
+		errs = append(errs, "the required flag `-p, --output-package' was not specified")
+	}
+	if len(errs) == 0 && o.pkgs != nil {
+		if err := o.ToConfig().Outputs[0].Validate(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// ToConfig converts the command line options to a Config with a single output
+func (o *Opts) ToConfig() *Config {
+	outputFile := o.OutputFile
+	if outputFile == "-" {
+		outputFile = "" // Write to stdout
+	}
+	return &Config{
+		Module: o.Module,
+		Outputs: []mockgen.Config{{
+			File:                  outputFile,
+			Layout:                o.Layout,
+			OutputDir:             o.OutputDir,
+			FileTemplate:          o.FileTemplate,
+			Package:               o.OutputPackage,
+			Module:                o.Module,
+			Expectations:          o.Expectations,
+			NoInterfaceAssertions: o.NoInterfaceAssertions,
+			Spy:                   o.Spy,
+			Strictness:            o.Strictness,
+			Backend:               o.Backend,
+			Fatal:                 o.Fatal,
+			Packages:              o.pkgs,
+		}},
+	}
+}
+
+// ApplyTo applies the options which may be given with --config to output, overriding the config file
+func (o *Opts) ApplyTo(output *mockgen.Config) {
+	if output.Module == "" {
+		output.Module = o.Module
+	}
+	output.Expectations = output.Expectations || o.Expectations
+	output.NoInterfaceAssertions = output.NoInterfaceAssertions || o.NoInterfaceAssertions
+	output.Spy = output.Spy || o.Spy
+	if o.Strictness != "" {
+		output.Strictness = o.Strictness
+	}
+	if o.Backend != "" {
+		output.Backend = o.Backend
+	}
+	output.Fatal = output.Fatal || o.Fatal
 }
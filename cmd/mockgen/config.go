@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/squizzling/mockgen/pkg/mockgen"
+)
+
+// Config describes a set of mocks to generate. It is loaded from a YAML file with --config, and as YAML is a
+// superset of JSON, a JSON file may be used instead.
+type Config struct {
+	Module  string           `yaml:"module"`
+	Outputs []mockgen.Config `yaml:"outputs"`
+}
+
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err = decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if len(cfg.Outputs) == 0 {
+		return nil, fmt.Errorf("%s: no outputs defined", path)
+	}
+
+	for idx := range cfg.Outputs {
+		output := &cfg.Outputs[idx]
+		if err = output.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: output %d: %w", path, idx, err)
+		}
+		if output.File == "" && (output.Layout == "" || output.Layout == mockgen.LayoutFile) {
+			return nil, fmt.Errorf("%s: output %d: file is required", path, idx)
+		}
+		if output.Module == "" {
+			output.Module = cfg.Module
+		}
+	}
+	return &cfg, nil
+}
+
+// AllPackages merges the packages of every output, so they can be loaded together
+func (c *Config) AllPackages() map[string]map[string]string {
+	allPkgs := make(map[string]map[string]string)
+	for _, output := range c.Outputs {
+		for pkgPath, interfaces := range output.Packages {
+			if _, ok := allPkgs[pkgPath]; !ok {
+				allPkgs[pkgPath] = make(map[string]string)
+			}
+			for ifaceName, structName := range interfaces {
+				allPkgs[pkgPath][ifaceName] = structName
+			}
+		}
+	}
+	return allPkgs
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numberedLines returns the lines 1 to n, with any line in replace replaced by its value
+func numberedLines(n int, replace map[int]string) string {
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		line, ok := replace[i]
+		if !ok {
+			line = strconv.Itoa(i)
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{
+			name:   "identical",
+			before: numberedLines(10, nil),
+			after:  numberedLines(10, nil),
+			want:   "",
+		},
+		{
+			name:   "single change",
+			before: numberedLines(10, nil),
+			after:  numberedLines(10, map[int]string{5: "five"}),
+			want: `@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+		},
+		{
+			name:   "changes at the edges",
+			before: numberedLines(20, nil),
+			after:  numberedLines(20, map[int]string{2: "two", 18: "eighteen"}),
+			want: `@@ -1,5 +1,5 @@
+ 1
+-2
++two
+ 3
+ 4
+ 5
+@@ -15,6 +15,6 @@
+ 15
+ 16
+ 17
+-18
++eighteen
+ 19
+ 20
+`,
+		},
+		{
+			name:   "hunks joined by shared context",
+			before: numberedLines(12, nil),
+			after:  numberedLines(12, map[int]string{3: "three", 10: "ten"}),
+			want: `@@ -1,12 +1,12 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+ 7
+ 8
+ 9
+-10
++ten
+ 11
+ 12
+`,
+		},
+		{
+			name:   "hunks split by unchanged lines",
+			before: numberedLines(14, nil),
+			after:  numberedLines(14, map[int]string{3: "three", 11: "eleven"}),
+			want: `@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -8,7 +8,7 @@
+ 8
+ 9
+ 10
+-11
++eleven
+ 12
+ 13
+ 14
+`,
+		},
+		{
+			name:   "insert and delete",
+			before: "a\nb\nc\n",
+			after:  "a\nc\nd\n",
+			want: `@@ -1,3 +1,3 @@
+ a
+-b
+ c
++d
+`,
+		},
+		{
+			name:   "new file",
+			before: "",
+			after:  "a\nb\n",
+			want: `@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			name:   "deleted file",
+			before: "a\nb\n",
+			after:  "",
+			want: `@@ -1,2 +0,0 @@
+-a
+-b
+`,
+		},
+		{
+			name:   "no newline at end of file",
+			before: "a\nb\n",
+			after:  "a\nb",
+			want: `@@ -1,2 +1,2 @@
+ a
+-b
++b
+\ No newline at end of file
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want != "" {
+				want = "--- before\n+++ after\n" + want
+			}
+			if got := UnifiedDiff("before", "after", tt.before, tt.after); got != want {
+				t.Errorf("UnifiedDiff() =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		edits  int
+	}{
+		{name: "large new file", before: "", after: numberedLines(100000, nil), edits: 100000},
+		{name: "large deleted file", before: numberedLines(100000, nil), after: "", edits: 100000},
+		{name: "large file with a change", before: numberedLines(100000, nil), after: numberedLines(100000, map[int]string{50000: "changed"}), edits: 2},
+		{name: "replaced", before: numberedLines(500, nil), after: numberedLines(500, map[int]string{1: "x", 500: "y"}), edits: 4},
+		{name: "interleaved", before: "a\nb\nc\na\nb\nb\na\n", after: "c\nb\na\nb\na\nc\n", edits: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := splitLines(tt.before), splitLines(tt.after)
+			ops := diffLines(a, b)
+
+			var gotA, gotB []string
+			edits := 0
+			for _, op := range ops {
+				if op.kind != '+' {
+					gotA = append(gotA, op.line)
+				}
+				if op.kind != '-' {
+					gotB = append(gotB, op.line)
+				}
+				if op.kind != ' ' {
+					edits++
+				}
+			}
+			if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+				t.Fatalf("diffLines() does not transform before into after")
+			}
+			if edits != tt.edits {
+				t.Errorf("diffLines() made %d edits, want %d", edits, tt.edits)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+	"golang.org/x/tools/go/packages"
+
+	"github.com/squizzling/mockgen/internal/args"
+	"github.com/squizzling/mockgen/pkg/mockgen"
+)
+
+// generatePrefix starts a go:generate directive, and is followed by a space or tab
+const generatePrefix = "//go:generate"
+
+// goRunValueFlags are the flags of go run which take a value, which may be given as a separate word
+var goRunValueFlags = map[string]bool{
+	"asmflags": true, "buildmode": true, "C": true, "compiler": true, "covermode": true, "coverpkg": true,
+	"exec": true, "gccgoflags": true, "gcflags": true, "installsuffix": true, "ldflags": true, "mod": true,
+	"modfile": true, "overlay": true, "p": true, "pgo": true, "pkgdir": true, "tags": true, "toolexec": true,
+}
+
+// GenerateOpts are the options for mockgen generate, which runs every mockgen go:generate directive in the matching
+// packages, loading the packages they need in a single call
+type GenerateOpts struct {
+	Chdir      string   `short:"C" long:"chdir" description:"Directory to run from"`
+	Check      bool     `long:"check" description:"Compare the generated mocks to the existing files and exit with an error if they differ, without writing anything"`
+	Positional []string // Packages to search for directives, the default is ./...
+}
+
+// directive is a mockgen go:generate directive, and where it was found
+type directive struct {
+	pos     string
+	outputs []mockgen.Config
+}
+
+func generateMain(commandLine []string) {
+	var opts GenerateOpts
+	args.ParseArgs(commandLine, &opts)
+
+	if opts.Chdir != "" {
+		if err := os.Chdir(opts.Chdir); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to change to %s: %s\n", opts.Chdir, err)
+			os.Exit(1)
+		}
+	}
+
+	patterns := opts.Positional
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	directives, err := findDirectives(patterns)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := groupDirectives(directives)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	if len(cfg.Outputs) == 0 {
+		return // Loading no packages would load the current directory instead
+	}
+
+	pkgs, err := mockgen.LoadPackages(context.Background(), "", cfg.AllPackages())
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	generateOutputs(cfg.Outputs, pkgs, opts.Check)
+}
+
+// findDirectives scans the Go files of the packages matching patterns, including their tests, for go:generate
+// directives which run mockgen, and returns the outputs of each, with their paths resolved against the directory
+// containing the directive
+func findDirectives(patterns []string) ([]directive, error) {
+	pkgs, err := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles, Tests: true}, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+
+	var directives []directive
+	var errs []error
+	scanned := make(map[string]bool)
+	reported := make(map[string]bool)
+	for _, pkg := range pkgs {
+		if strings.HasSuffix(pkg.ID, ".test") {
+			continue // The generated test main has no directives
+		}
+		// A package with tests is loaded again with its test files, so most files and errors are seen twice
+		for _, pkgErr := range pkg.Errors {
+			if err := fmt.Errorf("error loading package %s: %w", pkg.PkgPath, pkgErr); !reported[err.Error()] {
+				reported[err.Error()] = true
+				errs = append(errs, err)
+			}
+		}
+		for _, goFile := range pkg.GoFiles {
+			if scanned[goFile] {
+				continue
+			}
+			scanned[goFile] = true
+			found, err := findFileDirectives(pkg, goFile)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			directives = append(directives, found...)
+		}
+	}
+	return directives, errors.Join(errs...)
+}
+
+// findFileDirectives returns the mockgen directives in goFile, using the same rules as go generate to find them
+func findFileDirectives(pkg *packages.Package, goFile string) ([]directive, error) {
+	data, err := os.ReadFile(goFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var directives []directive
+	var errs []error
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text, ok := cutDirective(scanner.Text())
+		if !ok {
+			continue
+		}
+		pos := fmt.Sprintf("%s:%d", goFile, line)
+
+		words, err := splitDirective(text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pos, err))
+			continue
+		}
+		expandDirective(words, goFile, line, pkg.Name)
+
+		commandLine, ok := mockgenArgs(words)
+		if !ok {
+			continue
+		}
+		outputs, err := parseDirective(filepath.Dir(goFile), commandLine)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", pos, err))
+			continue
+		}
+		directives = append(directives, directive{pos: pos, outputs: outputs})
+	}
+	if err = scanner.Err(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to read %s: %w", goFile, err))
+	}
+	return directives, errors.Join(errs...)
+}
+
+// cutDirective returns the text of line after generatePrefix, if line is a go:generate directive
+func cutDirective(line string) (string, bool) {
+	text, ok := strings.CutPrefix(line, generatePrefix)
+	if !ok || text == "" || (text[0] != ' ' && text[0] != '\t') {
+		return "", false
+	}
+	return text, true
+}
+
+// splitDirective splits a go:generate directive into words, which are separated by spaces, or are a double quoted Go
+// string
+func splitDirective(line string) ([]string, error) {
+	var words []string
+	for line = strings.TrimLeft(line, " \t"); line != ""; line = strings.TrimLeft(line, " \t") {
+		if line[0] != '"' {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			words = append(words, line[:end])
+			line = line[end:]
+			continue
+		}
+
+		end := 1
+		for ; end < len(line) && line[end] != '"'; end++ {
+			if line[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(line) {
+			return nil, errors.New("unterminated quoted string")
+		}
+		word, err := strconv.Unquote(line[:end+1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s: %w", line[:end+1], err)
+		}
+		words = append(words, word)
+		line = line[end+1:]
+	}
+	return words, nil
+}
+
+// expandDirective expands the variables go generate provides in each word of a directive on line of goFile, in the
+// package named pkgName, and any environment variables
+func expandDirective(words []string, goFile string, line int, pkgName string) {
+	for idx, word := range words {
+		words[idx] = os.Expand(word, func(name string) string {
+			switch name {
+			case "GOFILE":
+				return filepath.Base(goFile)
+			case "GOLINE":
+				return strconv.Itoa(line)
+			case "GOPACKAGE":
+				return pkgName
+			case "DOLLAR":
+				return "$"
+			default:
+				return os.Getenv(name)
+			}
+		})
+	}
+}
+
+// mockgenArgs returns the arguments passed to mockgen by a directive, if it runs mockgen, either directly or with
+// go run
+func mockgenArgs(words []string) ([]string, bool) {
+	if len(words) == 0 {
+		return nil, false
+	}
+
+	commandLine := words[1:]
+	if filepath.Base(words[0]) != "mockgen" {
+		if words[0] != "go" || len(words) < 3 || words[1] != "run" {
+			return nil, false
+		}
+		idx := 2
+		for idx < len(words) && strings.HasPrefix(words[idx], "-") {
+			flag := strings.TrimLeft(words[idx], "-")
+			if !strings.Contains(flag, "=") && goRunValueFlags[flag] {
+				idx++ // The value is the next word
+			}
+			idx++
+		}
+		if idx >= len(words) {
+			return nil, false
+		}
+		pkgPath, _, _ := strings.Cut(words[idx], "@")
+		if pkgPath != "github.com/squizzling/mockgen/cmd/mockgen" && !strings.HasSuffix(pkgPath, "/cmd/mockgen") {
+			return nil, false
+		}
+		commandLine = words[idx+1:]
+	}
+
+	if len(commandLine) > 0 && commandLine[0] == "generate" {
+		return nil, false // Running generate from a directive would find the directive again
+	}
+	return commandLine, true
+}
+
+// parseDirective parses the arguments of a mockgen directive in dir, and returns the outputs it generates, with their
+// paths resolved against dir as go generate would run the directive from it
+func parseDirective(dir string, commandLine []string) ([]mockgen.Config, error) {
+	var opts Opts
+	opts.Input = parseInput(&opts)
+	if _, err := flags.NewParser(&opts, flags.PassDoubleDash).ParseArgs(commandLine); err != nil {
+		return nil, err
+	}
+	if errs := opts.Validate(); len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, ", "))
+	}
+
+	if opts.Chdir != "" {
+		dir = relativeTo(dir, opts.Chdir)
+	}
+
+	cfg := opts.ToConfig()
+	if opts.ConfigFile != "" {
+		var err error
+		if cfg, err = LoadConfig(relativeTo(dir, opts.ConfigFile)); err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+	}
+
+	for idx := range cfg.Outputs {
+		output := &cfg.Outputs[idx]
+		opts.ApplyTo(output)
+		if output.Layout == "" || output.Layout == mockgen.LayoutFile {
+			if output.File == "" {
+				return nil, errors.New("mocks can not be written to stdout with mockgen generate")
+			}
+			output.File = relativeTo(dir, output.File)
+		} else {
+			output.OutputDir = relativeTo(dir, output.OutputDir)
+		}
+	}
+	return cfg.Outputs, nil
+}
+
+// relativeTo returns path, resolved relative to dir if it is not absolute
+func relativeTo(dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// groupDirectives combines the outputs of every directive into a single Config, so the packages they need can be
+// loaded together.  A directive which is repeated is only generated once, but different directives may not write to
+// the same place.
+func groupDirectives(directives []directive) (*Config, error) {
+	var cfg Config
+	seen := make(map[string]string)
+	outputs := make(map[string]mockgen.Config)
+	var errs []error
+	for _, d := range directives {
+		for _, output := range d.outputs {
+			target := output.File
+			if target == "" {
+				target = output.OutputDir
+			}
+			if pos, ok := seen[target]; ok {
+				if !reflect.DeepEqual(outputs[target], output) {
+					errs = append(errs, fmt.Errorf("%s: %s is also generated by %s", d.pos, target, pos))
+				}
+				continue
+			}
+			seen[target] = d.pos
+			outputs[target] = output
+			cfg.Outputs = append(cfg.Outputs, output)
+		}
+	}
+	return &cfg, errors.Join(errs...)
+}
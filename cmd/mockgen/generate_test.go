@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCutDirective(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{line: "//go:generate mockgen -e", want: " mockgen -e", wantOK: true},
+		{line: "//go:generate\tmockgen -e", want: "\tmockgen -e", wantOK: true},
+		{line: "//go:generate", wantOK: false},
+		{line: "//go:generatemockgen", wantOK: false},
+		{line: "// go:generate mockgen", wantOK: false},
+		{line: "\t//go:generate mockgen", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, ok := cutDirective(tt.line)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("cutDirective(%q) = %q, %v, want %q, %v", tt.line, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSplitDirective(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty", line: "", want: nil},
+		{name: "words", line: "mockgen -i pkg:Iface -f mocks.go", want: []string{"mockgen", "-i", "pkg:Iface", "-f", "mocks.go"}},
+		{name: "extra whitespace", line: " \tmockgen  -e\t-p mocks ", want: []string{"mockgen", "-e", "-p", "mocks"}},
+		{name: "quoted", line: `mockgen -i "pkg:A, B" -f x.go`, want: []string{"mockgen", "-i", "pkg:A, B", "-f", "x.go"}},
+		{name: "escaped quote", line: `mockgen --file-template "a\"b.go"`, want: []string{"mockgen", "--file-template", `a"b.go`}},
+		{name: "escaped backslash", line: `mockgen "a\\" b`, want: []string{"mockgen", `a\`, "b"}},
+		{name: "quote at end", line: `mockgen ""`, want: []string{"mockgen", ""}},
+		{name: "unterminated", line: `mockgen "pkg:A`, wantErr: true},
+		{name: "unterminated after escape", line: `mockgen "pkg:A\"`, wantErr: true},
+		{name: "invalid escape", line: `mockgen "\q"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitDirective(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitDirective(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitDirective(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandDirective(t *testing.T) {
+	t.Setenv("MOCKGEN_TEST_VAR", "value")
+	tests := []struct {
+		name  string
+		words []string
+		want  []string
+	}{
+		{name: "no variables", words: []string{"mockgen", "-f", "mocks.go"}, want: []string{"mockgen", "-f", "mocks.go"}},
+		{name: "GOFILE", words: []string{"-f", "mock_$GOFILE"}, want: []string{"-f", "mock_store.go"}},
+		{name: "braces", words: []string{"-f", "${GOFILE}.mock"}, want: []string{"-f", "store.go.mock"}},
+		{name: "GOLINE", words: []string{"$GOLINE"}, want: []string{"12"}},
+		{name: "GOPACKAGE", words: []string{"-p", "$GOPACKAGE"}, want: []string{"-p", "store"}},
+		{name: "DOLLAR", words: []string{"$DOLLAR{GOFILE}"}, want: []string{"${GOFILE}"}},
+		{name: "environment", words: []string{"$MOCKGEN_TEST_VAR"}, want: []string{"value"}},
+		{name: "unset", words: []string{"a${MOCKGEN_TEST_UNSET}b"}, want: []string{"ab"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			words := append([]string(nil), tt.words...)
+			expandDirective(words, "/src/store/store.go", 12, "store")
+			if !reflect.DeepEqual(words, tt.want) {
+				t.Errorf("expandDirective(%q) = %q, want %q", tt.words, words, tt.want)
+			}
+		})
+	}
+}
+
+func TestMockgenArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		words  []string
+		want   []string
+		wantOK bool
+	}{
+		{name: "empty", words: nil},
+		{name: "mockgen", words: []string{"mockgen", "-i", "pkg:Iface"}, want: []string{"-i", "pkg:Iface"}, wantOK: true},
+		{name: "mockgen path", words: []string{"/go/bin/mockgen", "-e"}, want: []string{"-e"}, wantOK: true},
+		{name: "mockgen without args", words: []string{"mockgen"}, want: []string{}, wantOK: true},
+		{name: "other command", words: []string{"stringer", "-type", "Kind"}},
+		{name: "go run", words: []string{"go", "run", "github.com/squizzling/mockgen/cmd/mockgen", "-e"}, want: []string{"-e"}, wantOK: true},
+		{name: "go run version", words: []string{"go", "run", "github.com/squizzling/mockgen/cmd/mockgen@v1.2.3", "-e"}, want: []string{"-e"}, wantOK: true},
+		{name: "go run flags", words: []string{"go", "run", "-mod=mod", "github.com/squizzling/mockgen/cmd/mockgen@latest", "-i", "pkg:A"}, want: []string{"-i", "pkg:A"}, wantOK: true},
+		{name: "go run fork", words: []string{"go", "run", "example.com/fork/cmd/mockgen", "-e"}, want: []string{"-e"}, wantOK: true},
+		{name: "go run other", words: []string{"go", "run", "golang.org/x/tools/cmd/stringer@latest", "-type", "Kind"}},
+		{name: "go run only flags", words: []string{"go", "run", "-mod=mod"}},
+		{name: "go run value flag", words: []string{"go", "run", "-tags", "foo", "github.com/squizzling/mockgen/cmd/mockgen", "-e"}, want: []string{"-e"}, wantOK: true},
+		{name: "go run double dash value flag", words: []string{"go", "run", "--ldflags", "-s -w", "-mod=mod", "example.com/cmd/mockgen", "-e"}, want: []string{"-e"}, wantOK: true},
+		{name: "go run joined value flag", words: []string{"go", "run", "-tags=foo", "-race", "example.com/cmd/mockgen@v1.0.0", "-e"}, want: []string{"-e"}, wantOK: true},
+		{name: "go run missing value", words: []string{"go", "run", "-tags"}},
+		{name: "go build", words: []string{"go", "build", "github.com/squizzling/mockgen/cmd/mockgen"}},
+		{name: "generate", words: []string{"mockgen", "generate", "./..."}},
+		{name: "go run generate", words: []string{"go", "run", "github.com/squizzling/mockgen/cmd/mockgen@v1.2.3", "generate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := mockgenArgs(tt.words)
+			if ok != tt.wantOK {
+				t.Fatalf("mockgenArgs(%q) ok = %v, want %v", tt.words, ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mockgenArgs(%q) = %q, want %q", tt.words, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,74 +1,153 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 
 	"golang.org/x/tools/go/packages"
 
 	"github.com/squizzling/mockgen/internal/args"
+	"github.com/squizzling/mockgen/pkg/mockgen"
 )
 
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		generateMain(os.Args[2:])
+		return
+	}
+
+	var opts Opts
+	opts.Input = parseInput(&opts)
+	args.ParseArgs(os.Args[1:], &opts)
 
-func MustLoadPackages(packagesToGenerate map[string]map[string]string) []*packages.Package {
-	cfg := &packages.Config{
-		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule | packages.NeedImports,
-		Tests: false,
+	if opts.Chdir != "" {
+		if err := os.Chdir(opts.Chdir); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Failed to change to %s: %s\n", opts.Chdir, err)
+			os.Exit(1)
+		}
 	}
 
-	pkgNames := make([]string, 0, len(packagesToGenerate))
-	for pkgName, _ := range packagesToGenerate {
-		pkgNames = append(pkgNames, pkgName)
+	cfg := opts.ToConfig()
+	if opts.ConfigFile != "" {
+		var err error
+		if cfg, err = LoadConfig(opts.ConfigFile); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to load config: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	for idx := range cfg.Outputs {
+		opts.ApplyTo(&cfg.Outputs[idx])
 	}
 
-	pkgs, err := packages.Load(cfg, pkgNames...)
+	pkgs, err := mockgen.LoadPackages(context.Background(), "", cfg.AllPackages())
 	if err != nil {
-		fmt.Printf("failed to load packages: %s\n", err)
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
 		os.Exit(1)
 	}
 
-	anyErr := false
-	for _, pkg := range pkgs {
-		for _, err = range pkg.Errors {
-			fmt.Printf("error loading package %s: %s\n", pkg.PkgPath, err)
-			anyErr = true
+	generateOutputs(cfg.Outputs, pkgs, opts.Check)
+}
+
+// generateOutputs generates every output from the loaded packages, and writes them, or checks them if check is set.
+// It exits with an error if anything fails, or if check is set and any output is out of date.
+func generateOutputs(outputs []mockgen.Config, pkgs []*packages.Package, check bool) {
+	var errs []error
+	outOfDate := false
+	for _, output := range outputs {
+		files, err := mockgen.GenerateFiles(context.Background(), output, pkgs...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		paths := make([]string, 0, len(files))
+		for path := range files {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			if check {
+				outOfDate = !checkOutput(path, files[path]) || outOfDate
+			} else {
+				if err = writeOutput(path, files[path]); err != nil {
+					errs = append(errs, err)
+				}
+			}
 		}
 	}
 
-	if anyErr {
+	if len(errs) > 0 {
+		_, _ = fmt.Fprintf(os.Stderr, "%s\n", errors.Join(errs...))
 		os.Exit(1)
 	}
 
-	return pkgs
+	if outOfDate {
+		os.Exit(1)
+	}
 }
 
-func main() {
-	var opts Opts
-	opts.Input = parseInput(&opts)
-	args.ParseArgs(os.Args[1:], &opts)
-
-	if opts.Chdir != "" {
-		if err := os.Chdir(opts.Chdir); err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "Failed to change to %s: %s\n", opts.Chdir, err)
-			os.Exit(1)
-		}
+// checkOutput compares the generated data to the existing outputFile, and writes a diff to stdout if they differ
+func checkOutput(outputFile string, data []byte) bool {
+	existing, err := os.ReadFile(outputFile)
+	if err != nil && !os.IsNotExist(err) {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to read %s: %s\n", outputFile, err)
+		return false
 	}
 
-	pkgs := MustLoadPackages(opts.pkgs)
-	g := NewGenerator(opts.OutputPackage, opts.Module, pkgs, opts.pkgs)
+	if bytes.Equal(existing, data) {
+		return true
+	}
 
-	data := g.Generate()
+	_, _ = os.Stdout.Write([]byte(UnifiedDiff(outputFile, outputFile+" (generated)", string(existing), string(data))))
+	_, _ = fmt.Fprintf(os.Stderr, "%s is out of date\n", outputFile)
+	return false
+}
 
-	if opts.OutputFile == "" {
-		_, _ = os.Stdout.Write([]byte(data))
-	} else {
-		f, err := os.Create(opts.OutputFile)
-		if err == nil {
-			_, err = f.Write([]byte(data))
-			_ = f.Close()
+// writeOutput writes data to outputFile, or stdout if it is empty.  The file is replaced atomically by renaming a
+// temporary file over it, so a failed write never leaves it truncated, and it is not touched if it is unchanged.
+func writeOutput(outputFile string, data []byte) error {
+	if outputFile == "" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write to stdout: %w", err)
 		}
-		if err != nil {
-			_, _ = fmt.Fprintf(os.Stderr, "failed to write %s: %s\n", opts.OutputFile, err)
+		return nil
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(outputFile); err == nil {
+		if existing, err := os.ReadFile(outputFile); err == nil && bytes.Equal(existing, data) {
+			return nil
 		}
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0o755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	f, err := os.CreateTemp(filepath.Dir(outputFile), "."+filepath.Base(outputFile)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
+	}
+	tmpFile := f.Name()
+
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpFile, mode)
+	}
+	if err == nil {
+		err = os.Rename(tmpFile, outputFile)
+	}
+	if err != nil {
+		_ = os.Remove(tmpFile)
+		return fmt.Errorf("failed to write %s: %w", outputFile, err)
 	}
+	return nil
 }
@@ -1,14 +1,15 @@
 module github.com/squizzling/mockgen
 
-go 1.20
+go 1.25.0
 
 require (
 	github.com/jessevdk/go-flags v1.5.0
-	golang.org/x/tools v0.1.8
+	golang.org/x/tools v0.44.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	golang.org/x/mod v0.5.1 // indirect
-	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
-	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
+	golang.org/x/mod v0.35.0 // indirect
+	golang.org/x/sync v0.20.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
 )
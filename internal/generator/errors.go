@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+)
+
+// GeneratorError is a problem found while generating mocks, with the position of the offending interface or method
+// when it is known
+type GeneratorError struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *GeneratorError) Error() string {
+	if e.Pos.IsValid() {
+		return fmt.Sprintf("%s: %s", e.Pos, e.Msg)
+	}
+	return e.Msg
+}
+
+// errorf records an error at the position of the interface or method currently being processed.  A type may be
+// rendered more than once, so an error which has already been recorded is not repeated.
+func (g *Generator) errorf(format string, args ...interface{}) {
+	genErr := &GeneratorError{Pos: g.pos, Msg: fmt.Sprintf(format, args...)}
+	for _, err := range g.errs {
+		if prevErr, ok := err.(*GeneratorError); ok && *prevErr == *genErr {
+			return
+		}
+	}
+	g.errs = append(g.errs, genErr)
+}
+
+// setPos sets the position reported by errorf
+func (g *Generator) setPos(pos token.Pos) {
+	g.pos = token.Position{}
+	if g.fset != nil && pos.IsValid() {
+		g.pos = g.fset.Position(pos)
+	}
+}
+
+// err returns every error recorded so far, or nil if there are none
+func (g *Generator) err() error {
+	return errors.Join(g.errs...)
+}
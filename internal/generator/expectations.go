@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RenderExpectation renders the expectation struct for methodDef, and the builder methods used to configure it
+func (g *Generator) RenderExpectation(mockName string, ifaceDef *IfaceWrapper, methodDef *FuncWrapper) string {
+	var sb fmtBuilder
+	typeParamNames := g.RenderTypeParamNames(ifaceDef)
+	expectationName := fmt.Sprintf("Mock%s%sExpectation", mockName, methodDef.Name)
+
+	longestFieldName := len("times")
+	for idx := range methodDef.Results {
+		longestFieldName = MaxInt(longestFieldName, len(fmt.Sprintf("p%d", idx)))
+	}
+
+	_, _ = sb.WriteStringf("// %s is an expected call to Mock%s.%s, created by Mock%s.Expect%s\n", expectationName, mockName, methodDef.Name, mockName, methodDef.Name)
+	_, _ = sb.WriteStringf("type %s%s struct {\n", expectationName, g.RenderTypeParams(ifaceDef))
+	_, _ = sb.WriteStringf("\t%-*s Mock%s%sCall%s\n", longestFieldName, "args", mockName, methodDef.Name, typeParamNames)
+	_, _ = sb.WriteStringf("\t%-*s string\n", longestFieldName, "desc")
+	_, _ = sb.WriteStringf("\t%-*s func(%s) bool\n", longestFieldName, "match", g.RenderFuncParams(methodDef))
+	_, _ = sb.WriteStringf("\t%-*s int\n", longestFieldName, "times")
+	_, _ = sb.WriteStringf("\t%-*s int\n", longestFieldName, "calls")
+	for idx := range methodDef.Results {
+		_, _ = sb.WriteStringf("\t%-*s %s\n", longestFieldName, fmt.Sprintf("p%d", idx), g.RenderResultType(methodDef, idx))
+	}
+	_, _ = sb.WriteStringf("}\n")
+
+	receiver := fmt.Sprintf("func (e *%s%s)", expectationName, typeParamNames)
+	returnType := fmt.Sprintf("*%s%s", expectationName, typeParamNames)
+
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("// Match replaces the default argument comparison with fn\n")
+	_, _ = sb.WriteStringf("%s Match(fn func(%s) bool) %s {\n", receiver, g.RenderFuncParams(methodDef), returnType)
+	_, _ = sb.WriteStringf("\te.match = fn\n")
+	_, _ = sb.WriteStringf("\te.desc = \"<matcher>\"\n")
+	_, _ = sb.WriteStringf("\treturn e\n")
+	_, _ = sb.WriteStringf("}\n")
+
+	if len(methodDef.Results) > 0 {
+		_, _ = sb.WriteStringf("\n")
+		_, _ = sb.WriteStringf("// Return sets the values returned when the expectation is matched\n")
+		_, _ = sb.WriteStringf("%s Return(%s) %s {\n", receiver, strings.TrimSuffix(strings.TrimPrefix(g.RenderFuncResults(methodDef), " ("), ")"), returnType)
+		_, _ = sb.WriteStringf("\t%s = %s\n", g.RenderExpectationResults(methodDef), g.RenderResultNames(methodDef))
+		_, _ = sb.WriteStringf("\treturn e\n")
+		_, _ = sb.WriteStringf("}\n")
+	}
+
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("// Times sets the exact number of calls expected, the default is 1\n")
+	_, _ = sb.WriteStringf("%s Times(n int) %s {\n", receiver, returnType)
+	_, _ = sb.WriteStringf("\te.times = n\n")
+	_, _ = sb.WriteStringf("\treturn e\n")
+	_, _ = sb.WriteStringf("}\n")
+
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("// AnyTimes allows the expectation to be matched any number of times, including none\n")
+	_, _ = sb.WriteStringf("%s AnyTimes() %s {\n", receiver, returnType)
+	_, _ = sb.WriteStringf("\te.times = -1\n")
+	_, _ = sb.WriteStringf("\treturn e\n")
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+// RenderExpect renders the method used to register an expectation for methodDef, and the method used to match calls
+// against the registered expectations
+func (g *Generator) RenderExpect(mockName string, ifaceDef *IfaceWrapper, methodDef *FuncWrapper) string {
+	var sb fmtBuilder
+	typeParamNames := g.RenderTypeParamNames(ifaceDef)
+	expectationType := fmt.Sprintf("Mock%s%sExpectation%s", mockName, methodDef.Name, typeParamNames)
+	callValue := g.RenderCallValue(mockName, ifaceDef, methodDef)
+
+	_, _ = sb.WriteStringf("// Expect%s registers an expected call to %s with the given arguments. Unmet expectations are reported when the test completes\n", methodDef.Name, methodDef.Name)
+	_, _ = sb.WriteStringf("func (m *Mock%s%s) Expect%s(%s) *%s {\n", mockName, typeParamNames, methodDef.Name, g.RenderFuncParams(methodDef), expectationType)
+	_, _ = sb.WriteStringf("\te := &%s{args: %s, times: 1}\n", expectationType, callValue)
+	_, _ = sb.WriteStringf("\te.desc = %s.Sprintf(\"%%+v\", e.args)\n", g.importAlias(importFmt))
+	_, _ = sb.WriteStringf("\tm.mu.Lock()\n")
+	_, _ = sb.WriteStringf("\tm.expect%s = append(m.expect%s, e)\n", methodDef.Name, methodDef.Name)
+	_, _ = sb.WriteStringf("\tm.mu.Unlock()\n")
+	_, _ = sb.WriteStringf("\tm.expectOnce.Do(func() { m.tb().Cleanup(m.AssertExpectations) })\n")
+	_, _ = sb.WriteStringf("\treturn e\n")
+	_, _ = sb.WriteStringf("}\n")
+
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("func (m *Mock%s%s) match%s(%s) *%s {\n", mockName, typeParamNames, methodDef.Name, g.RenderFuncParams(methodDef), expectationType)
+	_, _ = sb.WriteStringf("\tcall := %s\n", callValue)
+	_, _ = sb.WriteStringf("\tm.mu.Lock()\n")
+	_, _ = sb.WriteStringf("\tvar expectations []*%s\n", expectationType)
+	_, _ = sb.WriteStringf("\tfor _, e := range m.expect%s {\n", methodDef.Name)
+	_, _ = sb.WriteStringf("\t\tif e.times < 0 || e.calls < e.times {\n")
+	_, _ = sb.WriteStringf("\t\t\texpectations = append(expectations, e)\n")
+	_, _ = sb.WriteStringf("\t\t}\n")
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("\tm.mu.Unlock()\n")
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("\t// Matchers are called without the lock, so they may use the mock\n")
+	_, _ = sb.WriteStringf("\tfor _, e := range expectations {\n")
+	equal := g.importAlias(importAssert) + ".ObjectsAreEqual"
+	if g.options.Backend == BackendTesting {
+		equal = g.importAlias(importReflect) + ".DeepEqual"
+	}
+	_, _ = sb.WriteStringf("\t\tif e.match != nil && !e.match(%s) || e.match == nil && !%s(e.args, call) {\n", g.RenderFuncInvokeParams(methodDef), equal)
+	_, _ = sb.WriteStringf("\t\t\tcontinue\n")
+	_, _ = sb.WriteStringf("\t\t}\n")
+	_, _ = sb.WriteStringf("\t\tm.mu.Lock()\n")
+	_, _ = sb.WriteStringf("\t\texhausted := e.times >= 0 && e.calls >= e.times\n")
+	_, _ = sb.WriteStringf("\t\tif !exhausted {\n")
+	_, _ = sb.WriteStringf("\t\t\te.calls++\n")
+	_, _ = sb.WriteStringf("\t\t}\n")
+	_, _ = sb.WriteStringf("\t\tm.mu.Unlock()\n")
+	_, _ = sb.WriteStringf("\t\tif !exhausted {\n")
+	_, _ = sb.WriteStringf("\t\t\treturn e\n")
+	_, _ = sb.WriteStringf("\t\t}\n")
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("\treturn nil\n")
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+// RenderAssertExpectations renders the method which reports every expectation on the mock that was not met
+func (g *Generator) RenderAssertExpectations(mockName string, ifaceDef *IfaceWrapper) string {
+	var sb fmtBuilder
+	_, _ = sb.WriteStringf("// AssertExpectations fails the test if any expectation was not called the expected number of times. It is\n")
+	_, _ = sb.WriteStringf("// registered with TB.Cleanup by the first call to an Expect method\n")
+	_, _ = sb.WriteStringf("func (m *Mock%s%s) AssertExpectations() {\n", mockName, g.RenderTypeParamNames(ifaceDef))
+	_, _ = sb.WriteStringf("\tm.mu.Lock()\n")
+	_, _ = sb.WriteStringf("\tdefer m.mu.Unlock()\n")
+	for _, methodDef := range ifaceDef.Methods {
+		_, _ = sb.WriteStringf("\tfor _, e := range m.expect%s {\n", methodDef.Name)
+		_, _ = sb.WriteStringf("\t\tif e.times >= 0 && e.calls != e.times {\n")
+		_, _ = sb.WriteStringf("\t\t\t%s\n", g.RenderFailure(false, mockName+"."+methodDef.Name+" %s expected %d calls, got %d", "e.desc", "e.times", "e.calls"))
+		_, _ = sb.WriteStringf("\t\t}\n")
+		_, _ = sb.WriteStringf("\t}\n")
+	}
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+// RenderCallValue renders a composite literal of the call struct for methodDef, populated from its parameters
+func (g *Generator) RenderCallValue(mockName string, ifaceDef *IfaceWrapper, methodDef *FuncWrapper) string {
+	var sb fmtBuilder
+	_, _ = sb.WriteStringf("Mock%s%sCall%s{", mockName, methodDef.Name, g.RenderTypeParamNames(ifaceDef))
+	for idx := range methodDef.Params {
+		if idx > 0 {
+			_, _ = sb.WriteString(", ")
+		}
+		_, _ = sb.WriteStringf("%s: %s", methodDef.ParamFieldName(idx), methodDef.ParamName(idx))
+	}
+	_, _ = sb.WriteString("}")
+	return sb.String()
+}
+
+// RenderExpectationResults renders the result fields of an expectation, as e.p0, e.p1, ...
+func (g *Generator) RenderExpectationResults(methodDef *FuncWrapper) string {
+	results := make([]string, 0, len(methodDef.Results))
+	for idx := range methodDef.Results {
+		results = append(results, fmt.Sprintf("e.p%d", idx))
+	}
+	return strings.Join(results, ", ")
+}
+
+// RenderResultNames renders the names given to results by RenderFuncResults, as p0, p1, ...
+func (g *Generator) RenderResultNames(methodDef *FuncWrapper) string {
+	results := make([]string, 0, len(methodDef.Results))
+	for idx := range methodDef.Results {
+		results = append(results, fmt.Sprintf("p%d", idx))
+	}
+	return strings.Join(results, ", ")
+}
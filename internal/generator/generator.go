@@ -0,0 +1,1051 @@
+// Package generator implements the code generation behind package mockgen, rendering a mock for each interface in
+// packages loaded with go/packages.
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// The strictness of a mock determines how it handles a call with no Fn field, expectation, or Impl to handle it
+const (
+	StrictnessStrict       = "strict"         // Fail the test
+	StrictnessLooseZero    = "loose-zero"     // Return zero values
+	StrictnessLooseWithLog = "loose-with-log" // Return zero values, and log the call with TB.Logf
+)
+
+// The backend determines how a mock fails the test
+const (
+	BackendTestify = "testify" // Use github.com/stretchr/testify
+	BackendTesting = "testing" // Use only testing.TB, so the generated code has no dependencies
+)
+
+const (
+	importAssert  = "github.com/stretchr/testify/assert"
+	importFmt     = "fmt"
+	importReflect = "reflect"
+	importRequire = "github.com/stretchr/testify/require"
+	importSync    = "sync"
+	importTesting = "testing"
+	importUTF8    = "unicode/utf8"
+)
+
+// maxArgLength is the longest an argument may be when it is formatted in the message for an unhandled call
+const maxArgLength = 200
+
+// generatedImports are the imports used by the generated code itself, rather than the interfaces being mocked
+var generatedImports = []string{importSync, importTesting, importAssert, importRequire, importFmt, importReflect, importUTF8}
+
+// GeneratorOptions controls which optional features are included in the generated mocks
+type GeneratorOptions struct {
+	Expectations          bool
+	NoInterfaceAssertions bool
+
+	// Spy adds an Impl field to each mock, which calls are forwarded to when there is no Fn field or expectation to
+	// handle them
+	Spy bool
+
+	// Backend is BackendTestify or BackendTesting, BackendTestify is used if it is empty.  If Fatal is set, an
+	// unhandled call stops the test immediately, and so must be made from the goroutine running the test.
+	Backend string
+	Fatal   bool
+
+	// Strictness is how a mock handles a call with no Fn field, expectation, or Impl to handle it, unless it is
+	// overridden by the Strictness field of the mock.  StrictnessStrict is used if it is empty.
+	Strictness string
+
+	// OutputDir is the directory the generated code is written to, and is used to detect when the mocks are
+	// generated into the same package as the interfaces they implement.
+	OutputDir string
+}
+
+type Generator struct {
+	options                              GeneratorOptions
+	outputPackage                        string
+	selfPackagePath                      string
+	loadedPackages                       map[string]*packages.Package
+	module                               string
+	imports                              map[string]string
+	baseImports                          SetString
+	externalImports                      SetString
+	localImports                         SetString
+	thingsToGenerateSortedKeys           []string
+	thingsToGenerateInterfacesSortedKeys map[string][]string
+	thingsToGenerate                     map[string]map[string]string
+	fset                                 *token.FileSet
+	pos                                  token.Position
+	errs                                 []error
+}
+
+func NewGenerator(outputPackage string, module string, pkgs []*packages.Package, thingsToGenerate map[string]map[string]string, options GeneratorOptions) (*Generator, error) {
+	g := &Generator{
+		options:                              options,
+		outputPackage:                        outputPackage,
+		loadedPackages:                       make(map[string]*packages.Package),
+		module:                               module,
+		imports:                              make(map[string]string),
+		thingsToGenerateInterfacesSortedKeys: make(map[string][]string),
+		baseImports:                          make(SetString),
+		externalImports:                      make(SetString),
+		localImports:                         make(SetString),
+	}
+
+	outputDir, _ := filepath.Abs(options.OutputDir)
+	for _, pkg := range pkgs {
+		g.loadedPackages[pkg.PkgPath] = pkg
+		g.fset = pkg.Fset
+		if pkg.Name == outputPackage && len(pkg.GoFiles) > 0 && filepath.Dir(pkg.GoFiles[0]) == outputDir {
+			g.selfPackagePath = pkg.PkgPath
+		}
+	}
+
+	var err error
+	if g.thingsToGenerate, err = ExpandWildcards(g.loadedPackages, thingsToGenerate); err != nil {
+		g.errs = append(g.errs, err)
+	}
+
+	// this is dirty but it's getting late.
+	for k, v1 := range g.thingsToGenerate {
+		g.thingsToGenerateSortedKeys = append(g.thingsToGenerateSortedKeys, k)
+		for v2, _ := range v1 {
+			g.thingsToGenerateInterfacesSortedKeys[k] = append(g.thingsToGenerateInterfacesSortedKeys[k], v2)
+		}
+		sort.Strings(g.thingsToGenerateInterfacesSortedKeys[k])
+	}
+	sort.Strings(g.thingsToGenerateSortedKeys)
+
+	g.addImport(importSync, "sync")
+	g.addImport(importTesting, "testing")
+	g.addImport(importFmt, "fmt")
+	g.addImport(importUTF8, "utf8")
+	if g.options.Backend == BackendTesting {
+		if g.options.Expectations {
+			g.addImport(importReflect, "reflect")
+		}
+	} else {
+		if g.options.Fatal {
+			g.addImport(importRequire, "require")
+		}
+		if !g.options.Fatal || g.options.Expectations {
+			g.addImport(importAssert, "assert")
+		}
+	}
+
+	reservedNames := NewSetString([]string{outputPackage})
+	declared := make(map[string]string)
+
+	for _, packageName := range g.thingsToGenerateSortedKeys {
+		for _, sourceInterfaceName := range g.thingsToGenerateInterfacesSortedKeys[packageName] {
+			ifaceDef, err := g.FindInterfaceTypeInPackages(packageName, sourceInterfaceName)
+			if err != nil {
+				g.errs = append(g.errs, err)
+				continue
+			}
+			g.setPos(ifaceDef.Named.Obj().Pos())
+			g.checkNameConflicts(ifaceDef)
+			g.checkDeclConflicts(declared, g.thingsToGenerate[packageName][sourceInterfaceName], packageName, ifaceDef)
+			for idx, typeParam := range ifaceDef.TypeParams {
+				if ifaceDef.TypeParamExprs != nil && ifaceDef.TypeParamExprs[idx] != nil {
+					g.collectExprImports(ifaceDef.TypeParamExprs[idx], ifaceDef.typeParamInfo)
+				} else {
+					g.collectImport(typeParam.Constraint())
+				}
+			}
+			if g.canAssertInterface(ifaceDef) || g.hasImpl(ifaceDef) {
+				g.collectImport(ifaceDef.Named)
+			}
+			for _, methodDef := range ifaceDef.Methods {
+				g.setPos(methodDef.Pos)
+				g.collectFuncImports(methodDef)
+				for i := 0; i < len(methodDef.Params); i++ {
+					reservedNames.Add(methodDef.ParamName(i))
+				}
+			}
+		}
+	}
+
+	if err = g.err(); err != nil {
+		return nil, err
+	}
+
+	g.resolveImportAliases(reservedNames)
+
+	return g, nil
+}
+
+// checkNameConflicts reports an error for every method of ifaceDef which has the same name as a field or method
+// generated on the mock, as the mock could not implement both
+func (g *Generator) checkNameConflicts(ifaceDef *IfaceWrapper) {
+	for _, methodDef := range ifaceDef.Methods {
+		if methodDef.Name == "Strictness" {
+			g.setPos(methodDef.Pos)
+			g.errorf("method Strictness conflicts with the Strictness field of the mock")
+		}
+		if g.options.Spy && methodDef.Name == "Impl" {
+			g.setPos(methodDef.Pos)
+			g.errorf("method Impl conflicts with the Impl field generated for spies")
+		}
+		for _, name := range []string{methodDef.Name + "Calls", methodDef.Name + "CallCount"} {
+			if ifaceDef.HasMethod(name) {
+				g.setPos(methodDef.Pos)
+				g.errorf("method %s conflicts with the %s accessor generated for %s", name, name, methodDef.Name)
+			}
+		}
+		if g.options.Expectations && ifaceDef.HasMethod("Expect"+methodDef.Name) {
+			g.setPos(methodDef.Pos)
+			g.errorf("method Expect%s conflicts with the expectation builder generated for %s", methodDef.Name, methodDef.Name)
+		}
+	}
+	if g.options.Expectations && ifaceDef.HasMethod("AssertExpectations") {
+		g.errorf("method AssertExpectations conflicts with the method generated with expectations")
+	}
+	g.setPos(ifaceDef.Named.Obj().Pos())
+}
+
+// checkDeclConflicts reports an error for every top level declaration generated for the mock named mockName which
+// has the same name as one generated for another mock, as the names are formed by concatenation and so may collide,
+// such as MockUserStoreGetCall for both User.StoreGet and UserStore.Get.  declared maps each name generated so far to
+// the interface it was generated for.
+func (g *Generator) checkDeclConflicts(declared map[string]string, mockName string, packageName string, ifaceDef *IfaceWrapper) {
+	names := []string{"Mock" + mockName, "NewMock" + mockName}
+	for _, methodDef := range ifaceDef.Methods {
+		names = append(names, fmt.Sprintf("Mock%s%sCall", mockName, methodDef.Name))
+		if g.options.Expectations {
+			names = append(names, fmt.Sprintf("Mock%s%sExpectation", mockName, methodDef.Name))
+		}
+	}
+
+	source := fmt.Sprintf("%s.%s", packageName, ifaceDef.Named.Obj().Name())
+	for _, name := range names {
+		if other, ok := declared[name]; ok {
+			g.errorf("%s generated for %s conflicts with the %s generated for %s", name, source, name, other)
+			continue
+		}
+		declared[name] = source
+	}
+}
+
+// canAssertInterface indicates if the generated code can assert at compile time that the mock implements ifaceDef.
+// This is not possible for a generic interface, or an unexported interface in another package.
+func (g *Generator) canAssertInterface(ifaceDef *IfaceWrapper) bool {
+	if g.options.NoInterfaceAssertions || len(ifaceDef.TypeParams) > 0 {
+		return false
+	}
+	return g.canReferenceInterface(ifaceDef)
+}
+
+// canReferenceInterface indicates if the generated code can refer to the interface by name, which is not possible
+// for an unexported interface in another package
+func (g *Generator) canReferenceInterface(ifaceDef *IfaceWrapper) bool {
+	obj := ifaceDef.Named.Obj()
+	return obj.Exported() || obj.Pkg().Path() == g.selfPackagePath
+}
+
+// canReferenceAlias indicates if the generated code can refer to an alias by name, which is not possible for an
+// unexported alias in another package, so the aliased type is used instead
+func (g *Generator) canReferenceAlias(alias *types.Alias) bool {
+	obj := alias.Obj()
+	return obj.Exported() || obj.Pkg() == nil || obj.Pkg().Path() == g.selfPackagePath
+}
+
+// hasImpl indicates if the mock for ifaceDef has an Impl field to forward calls to.  It is omitted if the interface
+// can not be referenced.
+func (g *Generator) hasImpl(ifaceDef *IfaceWrapper) bool {
+	return g.options.Spy && g.canReferenceInterface(ifaceDef)
+}
+
+// RenderImplType renders the type of the Impl field, which is the interface instantiated with the type parameters of
+// the mock if it is generic
+func (g *Generator) RenderImplType(ifaceDef *IfaceWrapper) string {
+	return g.typeToString(ifaceDef.Named) + g.RenderTypeParamNames(ifaceDef)
+}
+
+func (g *Generator) typeToString(pType types.Type) string {
+	switch pType := pType.(type) {
+	case *types.Array:
+		return fmt.Sprintf("[%d]%s", pType.Len(), g.typeToString(pType.Elem()))
+	case *types.Basic:
+		return pType.String()
+	case *types.Chan:
+		if pType.Dir() == 1 {
+			return fmt.Sprintf("chan<- %s", g.typeToString(pType.Elem()))
+		} else if pType.Dir() == 2 {
+			return fmt.Sprintf("<-chan %s", g.typeToString(pType.Elem()))
+		} else {
+			return fmt.Sprintf("chan %s", g.typeToString(pType.Elem()))
+		}
+	case *types.Interface:
+		return g.RenderInterface(pType)
+	case *types.Alias:
+		if !g.canReferenceAlias(pType) {
+			return g.typeToString(types.Unalias(pType))
+		}
+		obj := pType.Obj()
+		objName := obj.Name()
+		if obj.Pkg() != nil && obj.Pkg().Path() != g.selfPackagePath {
+			objName = fmt.Sprintf("%s.%s", g.imports[obj.Pkg().Path()], objName)
+		}
+		if typeArgs := pType.TypeArgs(); typeArgs.Len() > 0 {
+			args := make([]string, 0, typeArgs.Len())
+			for i := 0; i < typeArgs.Len(); i++ {
+				args = append(args, g.typeToString(typeArgs.At(i)))
+			}
+			objName = fmt.Sprintf("%s[%s]", objName, strings.Join(args, ", "))
+		}
+		return objName
+	case *types.Named:
+		obj := pType.Obj()
+		objPkg := obj.Pkg()
+		objName := obj.Name()
+		if objPkg != nil && objPkg.Path() != g.selfPackagePath {
+			objName = fmt.Sprintf("%s.%s", g.imports[objPkg.Path()], objName)
+		}
+		if typeArgs := pType.TypeArgs(); typeArgs.Len() > 0 {
+			args := make([]string, 0, typeArgs.Len())
+			for i := 0; i < typeArgs.Len(); i++ {
+				args = append(args, g.typeToString(typeArgs.At(i)))
+			}
+			objName = fmt.Sprintf("%s[%s]", objName, strings.Join(args, ", "))
+		}
+		return objName
+	case *types.Map:
+		return fmt.Sprintf("map[%s]%s", g.typeToString(pType.Key()), g.typeToString(pType.Elem()))
+	case *types.Pointer:
+		return fmt.Sprintf("*%s", g.typeToString(pType.Elem()))
+	case *types.Signature:
+		return fmt.Sprintf("func%s", g.RenderParamResults(NewFunc("", pType)))
+	case *types.Slice:
+		return fmt.Sprintf("[]%s", g.typeToString(pType.Elem()))
+	case *types.Struct:
+		return g.RenderStruct(pType)
+	case *types.TypeParam:
+		return pType.Obj().Name()
+	case *types.Union:
+		var sb strings.Builder
+		for i := 0; i < pType.Len(); i++ {
+			if i > 0 {
+				sb.WriteString(" | ")
+			}
+			term := pType.Term(i)
+			if term.Tilde() {
+				sb.WriteByte('~')
+			}
+			sb.WriteString(g.typeToString(term.Type()))
+		}
+		return sb.String()
+	default:
+		g.errorf("unsupported type %s", pType)
+		return pType.String()
+	}
+}
+
+// RenderInterface renders an inline interface literal, embedded types first, followed by the explicit methods
+func (g *Generator) RenderInterface(iface *types.Interface) string {
+	if iface.NumEmbeddeds() == 0 && iface.NumExplicitMethods() == 0 {
+		return "interface{}"
+	}
+	if iface.IsImplicit() {
+		// Constraints such as [T ~int | string] are wrapped in an implicit interface, which must not be rendered
+		return g.typeToString(iface.EmbeddedType(0))
+	}
+
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		g.checkExported(iface.ExplicitMethod(i), "inline interface method")
+	}
+
+	var elems []string
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		elems = append(elems, g.typeToString(iface.EmbeddedType(i)))
+	}
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		method := iface.ExplicitMethod(i)
+		elems = append(elems, method.Name()+g.RenderParamResults(NewFunc(method.Name(), method.Type().(*types.Signature))))
+	}
+	return fmt.Sprintf("interface{ %s }", strings.Join(elems, "; "))
+}
+
+// RenderStruct renders an anonymous struct literal, including embedded fields and tags
+func (g *Generator) RenderStruct(st *types.Struct) string {
+	if st.NumFields() == 0 {
+		return "struct{}"
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		g.checkExported(st.Field(i), "anonymous struct field")
+	}
+
+	fields := make([]string, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		fieldDef := g.typeToString(field.Type())
+		if !field.Embedded() {
+			fieldDef = fmt.Sprintf("%s %s", field.Name(), fieldDef)
+		}
+		if tag := st.Tag(i); tag != "" {
+			if strings.Contains(tag, "`") {
+				fieldDef = fmt.Sprintf("%s %s", fieldDef, strconv.Quote(tag))
+			} else {
+				fieldDef = fmt.Sprintf("%s `%s`", fieldDef, tag)
+			}
+		}
+		fields = append(fields, fieldDef)
+	}
+	return fmt.Sprintf("struct{ %s }", strings.Join(fields, "; "))
+}
+
+// checkExported reports an error if obj, a member of an anonymous struct or inline interface, is unexported and
+// declared outside the package the mocks are generated into.  Such a type is a different type when written in another
+// package, so the generated code would not compile.
+func (g *Generator) checkExported(obj types.Object, what string) {
+	if !obj.Exported() && obj.Pkg() != nil && obj.Pkg().Path() != g.selfPackagePath {
+		g.errorf("%s %s is unexported, so it can not be used outside package %s", what, obj.Name(), obj.Pkg().Path())
+	}
+}
+
+// RenderTypeParams renders the type parameter list of a generic interface, including constraints
+func (g *Generator) RenderTypeParams(ifaceDef *IfaceWrapper) string {
+	if len(ifaceDef.TypeParams) == 0 {
+		return ""
+	}
+
+	params := make([]string, 0, len(ifaceDef.TypeParams))
+	for idx, typeParam := range ifaceDef.TypeParams {
+		params = append(params, fmt.Sprintf("%s %s", typeParam.Obj().Name(), g.RenderTypeParamConstraint(ifaceDef, idx)))
+	}
+	return fmt.Sprintf("[%s]", strings.Join(params, ", "))
+}
+
+// RenderTypeParamNames renders the type parameter names of a generic interface, for use in a receiver
+func (g *Generator) RenderTypeParamNames(ifaceDef *IfaceWrapper) string {
+	if len(ifaceDef.TypeParams) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(ifaceDef.TypeParams))
+	for _, typeParam := range ifaceDef.TypeParams {
+		names = append(names, typeParam.Obj().Name())
+	}
+	return fmt.Sprintf("[%s]", strings.Join(names, ", "))
+}
+
+func (g *Generator) RenderStructField(fn *FuncWrapper, maxLength int) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Fn%-*s func", maxLength, fn.Name))
+
+	sb.WriteString(g.RenderParamResults(fn))
+	return sb.String()
+}
+
+func (g *Generator) RenderParamResults(fn *FuncWrapper) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	for idx, p := range fn.Params {
+		if idx > 0 {
+			sb.WriteString(", ")
+		}
+
+		if p.Name() != "" {
+			sb.WriteString(p.Name())
+			sb.WriteByte(' ')
+		}
+		if fn.Variadic && idx == len(fn.Params)-1 {
+			sb.WriteString("...")
+		}
+		sb.WriteString(g.RenderParamType(fn, idx, true))
+	}
+
+	sb.WriteString(")")
+	switch len(fn.Results) {
+	case 0:
+	case 1:
+		sb.WriteByte(' ')
+		sb.WriteString(g.RenderResultType(fn, 0))
+	default:
+		sb.WriteString(" (")
+		for idx := range fn.Results {
+			if idx > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(g.RenderResultType(fn, idx))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+func (g *Generator) RenderFuncParams(fn *FuncWrapper) string {
+	var sb strings.Builder
+
+	for idx := range fn.Params {
+		if idx > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(fmt.Sprintf("%s ", fn.ParamName(idx)))
+		if fn.Variadic && idx == len(fn.Params)-1 {
+			sb.WriteString("...")
+		}
+		sb.WriteString(g.RenderParamType(fn, idx, true))
+	}
+	return sb.String()
+}
+
+func (g *Generator) RenderFuncInvokeParams(fn *FuncWrapper) string {
+	var sb strings.Builder
+
+	for idx := range fn.Params {
+		if idx > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(fn.ParamName(idx))
+		if fn.Variadic && idx == len(fn.Params)-1 {
+			sb.WriteString("...")
+		}
+	}
+	return sb.String()
+}
+
+func (g *Generator) RenderFuncResults(fn *FuncWrapper) string {
+	var sb strings.Builder
+
+	if len(fn.Results) == 0 {
+		return ""
+	}
+
+	sb.WriteString(" (")
+	for idx := range fn.Results {
+		if idx > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(fmt.Sprintf("p%d %s", idx, g.RenderResultType(fn, idx)))
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+func (g *Generator) FindInterfaceTypeInPackages(packagePath string, interfaceName string) (*IfaceWrapper, error) {
+	pkg, ok := g.loadedPackages[packagePath]
+	if !ok {
+		return nil, fmt.Errorf("package %s is not loaded", packagePath)
+	}
+	sourceInterfaceName := interfaceName
+	interfaceName, typeArgsPart, instantiate := strings.Cut(interfaceName, "[")
+	pkgScope := pkg.Types.Scope()
+	nameType, ok := pkgScope.Lookup(interfaceName).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("unable to find %s.%s (names: %s)", packagePath, interfaceName, strings.Join(pkgScope.Names(), ", "))
+	}
+
+	namedType, ok := nameType.Type().(*types.Named)
+	if !ok {
+		return nil, &GeneratorError{Pos: pkg.Fset.Position(nameType.Pos()), Msg: fmt.Sprintf("%s.%s is not a named type", packagePath, interfaceName)}
+	}
+
+	typeParams := namedType.TypeParams()
+	if instantiate {
+		// Type arguments are evaluated in the scope of the file declaring the interface, so its imports are visible
+		var typeArgs []types.Type
+		for _, typeArgExpr := range SplitTopLevel(strings.TrimSuffix(typeArgsPart, "]"), ',') {
+			typeArg, err := types.Eval(pkg.Fset, pkg.Types, nameType.Pos(), strings.TrimSpace(typeArgExpr))
+			if err != nil {
+				return nil, fmt.Errorf("invalid type argument %s for %s.%s: %w", typeArgExpr, packagePath, sourceInterfaceName, err)
+			} else if !typeArg.IsType() {
+				return nil, fmt.Errorf("invalid type argument %s for %s.%s: not a type", typeArgExpr, packagePath, sourceInterfaceName)
+			}
+			typeArgs = append(typeArgs, typeArg.Type)
+		}
+		instance, err := types.Instantiate(nil, namedType, typeArgs, true)
+		if err != nil {
+			return nil, fmt.Errorf("unable to instantiate %s.%s: %w", packagePath, sourceInterfaceName, err)
+		}
+		namedType, typeParams = instance.(*types.Named), nil
+	}
+
+	ifaceType, ok := namedType.Underlying().(*types.Interface)
+	if !ok {
+		return nil, &GeneratorError{Pos: pkg.Fset.Position(nameType.Pos()), Msg: fmt.Sprintf("%s.%s is not an interface", packagePath, interfaceName)}
+	} else if !ifaceType.IsMethodSet() {
+		return nil, &GeneratorError{Pos: pkg.Fset.Position(nameType.Pos()), Msg: fmt.Sprintf("%s.%s is a type constraint", packagePath, interfaceName)}
+	}
+	iw := NewInterface(ifaceType, typeParams)
+	iw.Named = namedType
+	g.attachSyntax(iw, nameType.Pos())
+	return iw, nil
+}
+
+func (g *Generator) collectImport(t types.Type) {
+	switch paramType := t.(type) {
+	case *types.Array:
+		g.collectImport(paramType.Elem())
+	case *types.Basic:
+	case *types.Chan:
+		g.collectImport(paramType.Elem())
+	case *types.Interface:
+		for i := 0; i < paramType.NumEmbeddeds(); i++ {
+			g.collectImport(paramType.EmbeddedType(i))
+		}
+		for i := 0; i < paramType.NumExplicitMethods(); i++ {
+			g.collectImport(paramType.ExplicitMethod(i).Type())
+		}
+	case *types.Map:
+		g.collectImport(paramType.Key())
+		g.collectImport(paramType.Elem())
+	case *types.Alias:
+		if !g.canReferenceAlias(paramType) {
+			g.collectImport(types.Unalias(paramType))
+			return
+		}
+		if pkg := paramType.Obj().Pkg(); pkg != nil {
+			g.addImport(pkg.Path(), pkg.Name())
+		}
+		for i := 0; i < paramType.TypeArgs().Len(); i++ {
+			g.collectImport(paramType.TypeArgs().At(i))
+		}
+	case *types.Named:
+		if paramType.Obj().Pkg() != nil {
+			pkg := paramType.Obj().Pkg()
+			g.addImport(string(pkg.Path()), pkg.Name())
+		} else if name := paramType.Obj().Name(); name != "error" && name != "comparable" {
+			g.errorf("unexpected type %s without a package", name)
+		}
+		for i := 0; i < paramType.TypeArgs().Len(); i++ {
+			g.collectImport(paramType.TypeArgs().At(i))
+		}
+	case *types.Pointer:
+		g.collectImport(paramType.Elem())
+	case *types.Signature:
+		for i := 0; i < paramType.Params().Len(); i++ {
+			g.collectImport(paramType.Params().At(i).Type())
+		}
+		for i := 0; i < paramType.Results().Len(); i++ {
+			g.collectImport(paramType.Results().At(i).Type())
+		}
+	case *types.Slice:
+		g.collectImport(paramType.Elem())
+	case *types.Struct:
+		for i := 0; i < paramType.NumFields(); i++ {
+			g.collectImport(paramType.Field(i).Type())
+		}
+	case *types.TypeParam:
+	case *types.Union:
+		for i := 0; i < paramType.Len(); i++ {
+			g.collectImport(paramType.Term(i).Type())
+		}
+	default:
+		g.errorf("unsupported type %s", paramType)
+	}
+}
+
+func (g *Generator) addImport(pkgPath string, alias string) {
+	if pkgPath == g.selfPackagePath {
+		return
+	}
+	g.imports[pkgPath] = alias
+	parts := strings.Split(pkgPath, "/")
+	if strings.HasPrefix(pkgPath, g.module) {
+		g.localImports.Add(pkgPath)
+	} else if strings.Contains(parts[0], ".") {
+		g.externalImports.Add(pkgPath)
+	} else {
+		g.baseImports.Add(pkgPath)
+	}
+}
+
+// resolveImportAliases assigns every import a unique alias, which does not clash with any of the reserved names.  The
+// imports used by the generated code itself are resolved first, followed by the base, external, and local imports in
+// sorted order, so the result is deterministic.  A clashing import is named after its parent directory if possible
+// (k8s.io/api/core/v1 becomes corev1), otherwise a numeric suffix is added.
+func (g *Generator) resolveImportAliases(reservedNames SetString) {
+	var ordered []string
+	for _, pkgPath := range generatedImports {
+		if _, ok := g.imports[pkgPath]; ok {
+			ordered = append(ordered, pkgPath)
+		}
+	}
+	generated := NewSetString(generatedImports)
+	for _, imports := range []SetString{g.baseImports, g.externalImports, g.localImports} {
+		for _, pkgPath := range imports.Sorted() {
+			if _, ok := generated[pkgPath]; !ok {
+				ordered = append(ordered, pkgPath)
+			}
+		}
+	}
+
+	usedNames := make(SetString)
+	for name := range reservedNames {
+		usedNames.Add(name)
+	}
+
+	for _, pkgPath := range ordered {
+		alias := g.imports[pkgPath]
+		if _, used := usedNames[alias]; used {
+			candidate := ""
+			if parts := strings.Split(pkgPath, "/"); len(parts) >= 2 {
+				candidate = sanitizeIdentifier(parts[len(parts)-2] + alias)
+			}
+			if _, used = usedNames[candidate]; used || candidate == "" {
+				for i := 1; ; i++ {
+					candidate = fmt.Sprintf("%s%d", alias, i)
+					if _, used = usedNames[candidate]; !used {
+						break
+					}
+				}
+			}
+			alias = candidate
+		}
+		usedNames.Add(alias)
+		g.imports[pkgPath] = alias
+	}
+}
+
+// importAlias returns the name used to refer to pkgPath in the generated code
+func (g *Generator) importAlias(pkgPath string) string {
+	return g.imports[pkgPath]
+}
+
+func (g *Generator) RenderImports(imports []string) string {
+	var sb fmtBuilder
+	for _, importPath := range imports {
+		importAlias := g.imports[string(importPath)]
+		if importAlias == "" || importAlias == importPath || strings.HasSuffix(importPath, "/"+importAlias) {
+			_, _ = sb.WriteStringf("\t\"%s\"\n", importPath)
+		} else {
+			_, _ = sb.WriteStringf("\t%s \"%s\"\n", importAlias, importPath)
+		}
+	}
+	return sb.String()
+}
+
+// RenderCallStruct renders the struct used to record the arguments of each call to methodDef
+func (g *Generator) RenderCallStruct(mockName string, ifaceDef *IfaceWrapper, methodDef *FuncWrapper) string {
+	var sb fmtBuilder
+	longestFieldName := 0
+	for idx := range methodDef.Params {
+		longestFieldName = MaxInt(longestFieldName, len(methodDef.ParamFieldName(idx)))
+	}
+
+	_, _ = sb.WriteStringf("// Mock%s%sCall records the arguments of a call to Mock%s.%s\n", mockName, methodDef.Name, mockName, methodDef.Name)
+	_, _ = sb.WriteStringf("type Mock%s%sCall%s struct {\n", mockName, methodDef.Name, g.RenderTypeParams(ifaceDef))
+	for idx := range methodDef.Params {
+		_, _ = sb.WriteStringf("\t%-*s %s\n", longestFieldName, methodDef.ParamFieldName(idx), g.RenderParamType(methodDef, idx, false))
+	}
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+// RenderCallAccessors renders the methods used to inspect the calls recorded for methodDef
+func (g *Generator) RenderCallAccessors(mockName string, ifaceDef *IfaceWrapper, methodDef *FuncWrapper) string {
+	var sb fmtBuilder
+	typeParamNames := g.RenderTypeParamNames(ifaceDef)
+	callType := fmt.Sprintf("Mock%s%sCall%s", mockName, methodDef.Name, typeParamNames)
+
+	_, _ = sb.WriteStringf("// %sCalls returns the arguments of every call to %s, in the order they were made\n", methodDef.Name, methodDef.Name)
+	_, _ = sb.WriteStringf("func (m *Mock%s%s) %sCalls() []%s {\n", mockName, typeParamNames, methodDef.Name, callType)
+	_, _ = sb.WriteStringf("\tm.mu.Lock()\n")
+	_, _ = sb.WriteStringf("\tdefer m.mu.Unlock()\n")
+	_, _ = sb.WriteStringf("\treturn append([]%s(nil), m.calls%s...)\n", callType, methodDef.Name)
+	_, _ = sb.WriteStringf("}\n")
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("// %sCallCount returns the number of calls to %s\n", methodDef.Name, methodDef.Name)
+	_, _ = sb.WriteStringf("func (m *Mock%s%s) %sCallCount() int {\n", mockName, typeParamNames, methodDef.Name)
+	_, _ = sb.WriteStringf("\tm.mu.Lock()\n")
+	_, _ = sb.WriteStringf("\tdefer m.mu.Unlock()\n")
+	_, _ = sb.WriteStringf("\treturn len(m.calls%s)\n", methodDef.Name)
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+func (g *Generator) RenderBody(mockName string, ifaceDef *IfaceWrapper, methodDef *FuncWrapper) string {
+	var sb fmtBuilder
+	typeParamNames := g.RenderTypeParamNames(ifaceDef)
+	_, _ = sb.WriteStringf("func (m *Mock%s%s) %s(%s)%s {\n", mockName, typeParamNames, methodDef.Name, g.RenderFuncParams(methodDef), g.RenderFuncResults(methodDef))
+	_, _ = sb.WriteStringf("\tm.mu.Lock()\n")
+	_, _ = sb.WriteStringf("\tm.calls%s = append(m.calls%s, %s)\n", methodDef.Name, methodDef.Name, g.RenderCallValue(mockName, ifaceDef, methodDef))
+	_, _ = sb.WriteStringf("\tm.mu.Unlock()\n")
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("\tif m.Fn%s != nil {\n", methodDef.Name)
+	if len(methodDef.Results) == 0 {
+		_, _ = sb.WriteStringf("\t\tm.Fn%s(%s)\n", methodDef.Name, g.RenderFuncInvokeParams(methodDef))
+		if g.options.Expectations {
+			_, _ = sb.WriteStringf("\t} else if m.match%s(%s) == nil {\n", methodDef.Name, g.RenderFuncInvokeParams(methodDef))
+			if g.hasImpl(ifaceDef) {
+				_, _ = sb.WriteStringf("\t\tif m.Impl != nil {\n")
+				_, _ = sb.WriteStringf("\t\t\tm.Impl.%s(%s)\n", methodDef.Name, g.RenderFuncInvokeParams(methodDef))
+				_, _ = sb.WriteStringf("\t\t\treturn\n")
+				_, _ = sb.WriteStringf("\t\t}\n")
+			}
+		} else {
+			if g.hasImpl(ifaceDef) {
+				_, _ = sb.WriteStringf("\t} else if m.Impl != nil {\n")
+				_, _ = sb.WriteStringf("\t\tm.Impl.%s(%s)\n", methodDef.Name, g.RenderFuncInvokeParams(methodDef))
+			}
+			_, _ = sb.WriteStringf("\t} else {\n")
+		}
+		_, _ = sb.WriteStringf("\t\tif m.TB != nil {\n")
+		_, _ = sb.WriteStringf("\t\t\tm.TB.Helper()\n")
+		_, _ = sb.WriteStringf("\t\t}\n")
+		_, _ = sb.WriteStringf("\t\tm.unhandled(%s)\n", g.RenderUnhandledArgs(mockName, methodDef))
+		_, _ = sb.WriteStringf("\t}\n")
+	} else {
+		_, _ = sb.WriteStringf("\t\treturn m.Fn%s(%s)\n", methodDef.Name, g.RenderFuncInvokeParams(methodDef))
+		_, _ = sb.WriteStringf("\t}\n")
+		if g.options.Expectations {
+			_, _ = sb.WriteStringf("\tif e := m.match%s(%s); e != nil {\n", methodDef.Name, g.RenderFuncInvokeParams(methodDef))
+			_, _ = sb.WriteStringf("\t\treturn %s\n", g.RenderExpectationResults(methodDef))
+			_, _ = sb.WriteStringf("\t}\n")
+		}
+		if g.hasImpl(ifaceDef) {
+			_, _ = sb.WriteStringf("\tif m.Impl != nil {\n")
+			_, _ = sb.WriteStringf("\t\treturn m.Impl.%s(%s)\n", methodDef.Name, g.RenderFuncInvokeParams(methodDef))
+			_, _ = sb.WriteStringf("\t}\n")
+		}
+		_, _ = sb.WriteStringf("\tif m.TB != nil {\n")
+		_, _ = sb.WriteStringf("\t\tm.TB.Helper()\n")
+		_, _ = sb.WriteStringf("\t}\n")
+		_, _ = sb.WriteStringf("\tm.unhandled(%s)\n", g.RenderUnhandledArgs(mockName, methodDef))
+		_, _ = sb.WriteStringf("\treturn\n")
+	}
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+// RenderFailure renders a statement which fails the test with a message formatted from format and args, which are
+// expressions in the generated code.  If fatal is set, the test is stopped immediately.
+func (g *Generator) RenderFailure(fatal bool, format string, args ...string) string {
+	if g.options.Backend == BackendTesting {
+		method := "Errorf"
+		if fatal {
+			method = "Fatalf"
+		}
+		return fmt.Sprintf("m.TB.%s(%s)", method, strings.Join(append([]string{strconv.Quote(format)}, args...), ", "))
+	}
+
+	importPath := importAssert
+	if fatal {
+		importPath = importRequire
+	}
+	msg := strconv.Quote(format)
+	if len(args) > 0 {
+		msg = fmt.Sprintf("%s.Sprintf(%s, %s)", g.importAlias(importFmt), msg, strings.Join(args, ", "))
+	}
+	return fmt.Sprintf("%s.Fail(m.TB, %s)", g.importAlias(importPath), msg)
+}
+
+// RenderUnhandledArgs renders the arguments passed to unhandled for a call to methodDef, which are the name of the
+// method and its parameters
+func (g *Generator) RenderUnhandledArgs(mockName string, methodDef *FuncWrapper) string {
+	args := []string{strconv.Quote(mockName + "." + methodDef.Name)}
+	for idx := range methodDef.Params {
+		args = append(args, methodDef.ParamName(idx))
+	}
+	return strings.Join(args, ", ")
+}
+
+// RenderUnhandled renders the method which handles a call with no Fn field, expectation, or Impl to handle it.  The
+// results of the call are always zero values, but depending on the strictness it may also fail the test or log it,
+// with the arguments of the call formatted with %#v and truncated to maxArgLength bytes without splitting a rune.
+func (g *Generator) RenderUnhandled(mockName string, ifaceDef *IfaceWrapper) string {
+	var sb fmtBuilder
+	strictness := g.options.Strictness
+	if strictness == "" {
+		strictness = StrictnessStrict
+	}
+	_, _ = sb.WriteStringf("// unhandled reports a call to method which was not handled, according to m.Strictness, or %q if it is empty\n", strictness)
+	_, _ = sb.WriteStringf("func (m *Mock%s%s) unhandled(method string, args ...interface{}) {\n", mockName, g.RenderTypeParamNames(ifaceDef))
+	_, _ = sb.WriteStringf("\tstrictness := m.Strictness\n")
+	_, _ = sb.WriteStringf("\tif strictness == \"\" {\n")
+	_, _ = sb.WriteStringf("\t\tstrictness = %q\n", strictness)
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("\tif strictness == %q {\n", StrictnessLooseZero)
+	_, _ = sb.WriteStringf("\t\treturn\n")
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("\tm.tb().Helper()\n")
+	_, _ = sb.WriteStringf("\tcall := \"\"\n")
+	_, _ = sb.WriteStringf("\tfor idx, arg := range args {\n")
+	_, _ = sb.WriteStringf("\t\tif idx > 0 {\n")
+	_, _ = sb.WriteStringf("\t\t\tcall += \", \"\n")
+	_, _ = sb.WriteStringf("\t\t}\n")
+	_, _ = sb.WriteStringf("\t\tvalue := %s.Sprintf(\"%%#v\", arg)\n", g.importAlias(importFmt))
+	_, _ = sb.WriteStringf("\t\tif len(value) > %d {\n", maxArgLength)
+	_, _ = sb.WriteStringf("\t\t\tend := %d\n", maxArgLength)
+	_, _ = sb.WriteStringf("\t\t\tfor end > 0 && !%s.RuneStart(value[end]) {\n", g.importAlias(importUTF8))
+	_, _ = sb.WriteStringf("\t\t\t\tend--\n")
+	_, _ = sb.WriteStringf("\t\t\t}\n")
+	_, _ = sb.WriteStringf("\t\t\tvalue = value[:end] + \"...\"\n")
+	_, _ = sb.WriteStringf("\t\t}\n")
+	_, _ = sb.WriteStringf("\t\tcall += value\n")
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("\tif strictness == %q {\n", StrictnessLooseWithLog)
+	_, _ = sb.WriteStringf("\t\tm.TB.Logf(\"%%s(%%s) was called with no handler, returning zero values\", method, call)\n")
+	_, _ = sb.WriteStringf("\t} else {\n")
+	_, _ = sb.WriteStringf("\t\t%s\n", g.RenderFailure(g.options.Fatal, "%s(%s) must not be called", "method", "call"))
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+// RenderConstructor renders the NewMock function, which creates a mock for tb and applies any options to it
+func (g *Generator) RenderConstructor(mockName string, ifaceDef *IfaceWrapper) string {
+	var sb fmtBuilder
+	mockType := fmt.Sprintf("Mock%s%s", mockName, g.RenderTypeParamNames(ifaceDef))
+	_, _ = sb.WriteStringf("// NewMock%s creates a Mock%s which reports failures to tb, and applies opts to it before it is returned.\n", mockName, mockName)
+	if g.options.Expectations {
+		_, _ = sb.WriteStringf("// Unmet expectations are reported when the test completes.\n")
+	}
+	_, _ = sb.WriteStringf("func NewMock%s%s(tb %s.TB, opts ...func(*%s)) *%s {\n", mockName, g.RenderTypeParams(ifaceDef), g.importAlias(importTesting), mockType, mockType)
+	_, _ = sb.WriteStringf("\tif tb == nil {\n")
+	_, _ = sb.WriteStringf("\t\tpanic(\"NewMock%s: tb must not be nil\")\n", mockName)
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("\tm := &%s{TB: tb}\n", mockType)
+	_, _ = sb.WriteStringf("\tfor _, opt := range opts {\n")
+	_, _ = sb.WriteStringf("\t\topt(m)\n")
+	_, _ = sb.WriteStringf("\t}\n")
+	if g.options.Expectations {
+		_, _ = sb.WriteStringf("\tm.expectOnce.Do(func() { tb.Cleanup(m.AssertExpectations) })\n")
+	}
+	_, _ = sb.WriteStringf("\treturn m\n")
+	_, _ = sb.WriteStringf("}\n")
+
+	_, _ = sb.WriteStringf("\n")
+	_, _ = sb.WriteStringf("// tb returns m.TB, or panics with a clear message if it is not set\n")
+	_, _ = sb.WriteStringf("func (m *%s) tb() %s.TB {\n", mockType, g.importAlias(importTesting))
+	_, _ = sb.WriteStringf("\tif m.TB == nil {\n")
+	_, _ = sb.WriteStringf("\t\tpanic(\"Mock%s.TB is nil, create the mock with NewMock%s or set TB\")\n", mockName, mockName)
+	_, _ = sb.WriteStringf("\t}\n")
+	_, _ = sb.WriteStringf("\treturn m.TB\n")
+	_, _ = sb.WriteStringf("}\n")
+	return sb.String()
+}
+
+func (g *Generator) Generate() (string, error) {
+	var sb fmtBuilder
+
+	_, _ = sb.WriteStringf("package %s\n", g.outputPackage)
+	_, _ = sb.WriteString("\n")
+
+	_, _ = sb.WriteString("// Code generated by github.com/squizzling/mockgen. DO NOT EDIT.\n")
+	_, _ = sb.WriteString("\n")
+
+	_, _ = sb.WriteStringf("import (\n")
+	_, _ = sb.WriteString(g.RenderImports(g.baseImports.Sorted()))
+	if len(g.externalImports) > 0 {
+		_, _ = sb.WriteStringf("\n")
+		_, _ = sb.WriteString(g.RenderImports(g.externalImports.Sorted()))
+	}
+	if len(g.localImports) > 0 {
+		_, _ = sb.WriteStringf("\n")
+		_, _ = sb.WriteString(g.RenderImports(g.localImports.Sorted()))
+	}
+	_, _ = sb.WriteStringf(")\n")
+
+	for _, packageName := range g.thingsToGenerateSortedKeys {
+		interfaceNames := g.thingsToGenerate[packageName]
+		interfaceNamesSorted := g.thingsToGenerateInterfacesSortedKeys[packageName]
+
+		for _, sourceInterfaceName := range interfaceNamesSorted {
+			generatedInterfaceName := interfaceNames[sourceInterfaceName]
+			ifaceDef, err := g.FindInterfaceTypeInPackages(packageName, sourceInterfaceName)
+			if err != nil {
+				g.errs = append(g.errs, err)
+				continue
+			}
+			_, _ = sb.WriteStringf("\n")
+			_, _ = sb.WriteStringf("// Mock%s implements a mock %s.%s from %s\n", generatedInterfaceName, g.loadedPackages[packageName].Name, sourceInterfaceName, packageName)
+			g.setPos(ifaceDef.Named.Obj().Pos())
+			_, _ = sb.WriteStringf("type Mock%s%s struct {\n", generatedInterfaceName, g.RenderTypeParams(ifaceDef))
+			_, _ = sb.WriteStringf("\tTB %s.TB\n", g.importAlias(importTesting))
+			if g.hasImpl(ifaceDef) {
+				_, _ = sb.WriteStringf("\tImpl %s\n", g.RenderImplType(ifaceDef))
+			}
+			_, _ = sb.WriteStringf("\tStrictness string\n")
+			_, _ = sb.WriteStringf("\n")
+			for _, methodDef := range ifaceDef.Methods {
+				_, _ = sb.WriteStringf("\t%s\n", g.RenderStructField(methodDef, ifaceDef.LongestMethodName))
+			}
+			_, _ = sb.WriteStringf("\n")
+			fieldWidth := ifaceDef.LongestMethodName + 5
+			if g.options.Expectations {
+				fieldWidth = MaxInt(ifaceDef.LongestMethodName+6, len("expectOnce"))
+			}
+			_, _ = sb.WriteStringf("\t%-*s %s.Mutex\n", fieldWidth, "mu", g.importAlias(importSync))
+			for _, methodDef := range ifaceDef.Methods {
+				_, _ = sb.WriteStringf("\t%-*s []Mock%s%sCall%s\n", fieldWidth, "calls"+methodDef.Name, generatedInterfaceName, methodDef.Name, g.RenderTypeParamNames(ifaceDef))
+			}
+			if g.options.Expectations {
+				_, _ = sb.WriteStringf("\t%-*s %s.Once\n", fieldWidth, "expectOnce", g.importAlias(importSync))
+				for _, methodDef := range ifaceDef.Methods {
+					_, _ = sb.WriteStringf("\t%-*s []*Mock%s%sExpectation%s\n", fieldWidth, "expect"+methodDef.Name, generatedInterfaceName, methodDef.Name, g.RenderTypeParamNames(ifaceDef))
+				}
+			}
+			_, _ = sb.WriteStringf("}\n")
+
+			if g.canAssertInterface(ifaceDef) {
+				_, _ = sb.WriteStringf("\n")
+				_, _ = sb.WriteStringf("var _ %s = (*Mock%s)(nil)\n", g.typeToString(ifaceDef.Named), generatedInterfaceName)
+			}
+
+			_, _ = sb.WriteStringf("\n")
+			_, _ = sb.WriteString(g.RenderConstructor(generatedInterfaceName, ifaceDef))
+
+			for _, methodDef := range ifaceDef.Methods {
+				_, _ = sb.WriteStringf("\n")
+				_, _ = sb.WriteString(g.RenderCallStruct(generatedInterfaceName, ifaceDef, methodDef))
+				if g.options.Expectations {
+					_, _ = sb.WriteStringf("\n")
+					_, _ = sb.WriteString(g.RenderExpectation(generatedInterfaceName, ifaceDef, methodDef))
+				}
+			}
+
+			if g.options.Expectations {
+				_, _ = sb.WriteStringf("\n")
+				_, _ = sb.WriteString(g.RenderAssertExpectations(generatedInterfaceName, ifaceDef))
+			}
+
+			_, _ = sb.WriteStringf("\n")
+			_, _ = sb.WriteString(g.RenderUnhandled(generatedInterfaceName, ifaceDef))
+
+			for _, methodDef := range ifaceDef.Methods {
+				_, _ = sb.WriteStringf("\n")
+				_, _ = sb.WriteString(g.RenderBody(generatedInterfaceName, ifaceDef, methodDef))
+				_, _ = sb.WriteStringf("\n")
+				_, _ = sb.WriteString(g.RenderCallAccessors(generatedInterfaceName, ifaceDef, methodDef))
+				if g.options.Expectations {
+					_, _ = sb.WriteStringf("\n")
+					_, _ = sb.WriteString(g.RenderExpect(generatedInterfaceName, ifaceDef, methodDef))
+				}
+			}
+		}
+	}
+
+	return sb.String(), g.err()
+}
+
+// FormatSource formats the generated source with go/format.  If the source can not be parsed, the returned error
+// includes the offending line of generated code.
+func FormatSource(src string) (string, error) {
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		var errList scanner.ErrorList
+		if errors.As(err, &errList) && len(errList) > 0 {
+			lines := strings.Split(src, "\n")
+			if line := errList[0].Pos.Line; line > 0 && line <= len(lines) {
+				return "", fmt.Errorf("%w\n%d:\t%s", err, line, lines[line-1])
+			}
+		}
+		return "", err
+	}
+	return string(formatted), nil
+}
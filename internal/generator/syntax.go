@@ -0,0 +1,350 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// findSyntax returns the file containing pos, and the package it belongs to, if the syntax for it was loaded
+func (g *Generator) findSyntax(pos token.Pos) (*ast.File, *packages.Package) {
+	if !pos.IsValid() {
+		return nil, nil
+	}
+	for _, pkg := range g.loadedPackages {
+		for _, file := range pkg.Syntax {
+			if file.Pos() <= pos && pos < file.End() {
+				return file, pkg
+			}
+		}
+	}
+	return nil, nil
+}
+
+// attachSyntax records the type expressions written in the source for the type parameters and methods of ifaceDef,
+// so aliases and the predeclared any and comparable are rendered as they were written.  An expression is only used
+// if it resolves to the same type as the method, which is not the case for an instantiated generic interface.
+func (g *Generator) attachSyntax(ifaceDef *IfaceWrapper, typeNamePos token.Pos) {
+	if file, pkg := g.findSyntax(typeNamePos); file != nil {
+		ast.Inspect(file, func(node ast.Node) bool {
+			typeSpec, ok := node.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Pos() != typeNamePos {
+				return true
+			}
+			exprs := flattenFields(typeSpec.TypeParams)
+			if len(exprs) != len(ifaceDef.TypeParams) {
+				return false
+			}
+			ifaceDef.TypeParamExprs = make([]ast.Expr, len(exprs))
+			ifaceDef.typeParamInfo = pkg.TypesInfo
+			for idx, expr := range exprs {
+				if exprType := pkg.TypesInfo.TypeOf(expr); exprType != nil && types.Identical(exprType, ifaceDef.TypeParams[idx].Constraint()) {
+					ifaceDef.TypeParamExprs[idx] = expr
+				}
+			}
+			return false
+		})
+	}
+
+	for _, methodDef := range ifaceDef.Methods {
+		file, pkg := g.findSyntax(methodDef.Pos)
+		if file == nil {
+			continue
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			field, ok := node.(*ast.Field)
+			if !ok || len(field.Names) != 1 || field.Names[0].Pos() != methodDef.Pos {
+				return true
+			}
+			if funcType, ok := field.Type.(*ast.FuncType); ok {
+				methodDef.ParamExprs = matchExprs(pkg.TypesInfo, flattenFields(funcType.Params), methodDef.Params)
+				methodDef.ResultExprs = matchExprs(pkg.TypesInfo, flattenFields(funcType.Results), methodDef.Results)
+				methodDef.info = pkg.TypesInfo
+			}
+			return false
+		})
+	}
+}
+
+// flattenFields returns the type of each entry in the field list, repeating the type of fields which declare
+// multiple names, such as (a, b int)
+func flattenFields(fields *ast.FieldList) []ast.Expr {
+	if fields == nil {
+		return nil
+	}
+	var exprs []ast.Expr
+	for _, field := range fields.List {
+		for i := 0; i < MaxInt(1, len(field.Names)); i++ {
+			exprs = append(exprs, field.Type)
+		}
+	}
+	return exprs
+}
+
+// matchExprs pairs each variable with its expression, leaving the expression nil when it does not resolve to the
+// type of the variable.  Variadic parameters are converted to slices, to match the type of the variable.
+func matchExprs(info *types.Info, exprs []ast.Expr, vars []*types.Var) []ast.Expr {
+	if len(exprs) != len(vars) {
+		return nil
+	}
+	matched := make([]ast.Expr, len(vars))
+	for idx, expr := range exprs {
+		exprType := info.TypeOf(expr)
+		if ellipsis, ok := expr.(*ast.Ellipsis); ok {
+			expr = &ast.ArrayType{Lbrack: ellipsis.Pos(), Elt: ellipsis.Elt}
+			if elemType := info.TypeOf(ellipsis.Elt); elemType != nil {
+				exprType = types.NewSlice(elemType)
+			}
+		}
+		if exprType != nil && types.Identical(exprType, vars[idx].Type()) {
+			matched[idx] = expr
+		}
+	}
+	return matched
+}
+
+// RenderParamType renders the type of the parameter at idx, using the source expression when it is available.  If
+// variadicElem is set and the parameter is variadic, the element type is rendered instead of the slice.
+func (g *Generator) RenderParamType(fn *FuncWrapper, idx int, variadicElem bool) string {
+	pType := fn.Params[idx].Type()
+	var expr ast.Expr
+	if fn.ParamExprs != nil {
+		expr = fn.ParamExprs[idx]
+	}
+	if variadicElem && fn.Variadic && idx == len(fn.Params)-1 {
+		pType = pType.(*types.Slice).Elem()
+		if expr != nil {
+			expr = expr.(*ast.ArrayType).Elt
+		}
+	}
+	if expr != nil {
+		return g.exprToString(expr, fn.info)
+	}
+	return g.typeToString(pType)
+}
+
+// RenderResultType renders the type of the result at idx, using the source expression when it is available
+func (g *Generator) RenderResultType(fn *FuncWrapper, idx int) string {
+	if fn.ResultExprs != nil && fn.ResultExprs[idx] != nil {
+		return g.exprToString(fn.ResultExprs[idx], fn.info)
+	}
+	return g.typeToString(fn.Results[idx].Type())
+}
+
+// RenderTypeParamConstraint renders the constraint of the type parameter at idx, using the source expression when
+// it is available
+func (g *Generator) RenderTypeParamConstraint(ifaceDef *IfaceWrapper, idx int) string {
+	if ifaceDef.TypeParamExprs != nil && ifaceDef.TypeParamExprs[idx] != nil {
+		return g.exprToString(ifaceDef.TypeParamExprs[idx], ifaceDef.typeParamInfo)
+	}
+	return g.typeToString(ifaceDef.TypeParams[idx].Constraint())
+}
+
+// collectFuncImports collects the imports required by the parameters and results of fn
+func (g *Generator) collectFuncImports(fn *FuncWrapper) {
+	for idx, p := range fn.Params {
+		if fn.ParamExprs != nil && fn.ParamExprs[idx] != nil {
+			g.collectExprImports(fn.ParamExprs[idx], fn.info)
+		} else {
+			g.collectImport(p.Type())
+		}
+	}
+	for idx, r := range fn.Results {
+		if fn.ResultExprs != nil && fn.ResultExprs[idx] != nil {
+			g.collectExprImports(fn.ResultExprs[idx], fn.info)
+		} else {
+			g.collectImport(r.Type())
+		}
+	}
+}
+
+// collectExprImports collects the imports required by every type referenced in expr
+func (g *Generator) collectExprImports(expr ast.Expr, info *types.Info) {
+	ast.Inspect(expr, func(node ast.Node) bool {
+		if expr, ok := node.(ast.Expr); ok && g.isHiddenAlias(expr, info) {
+			g.collectImport(types.Unalias(info.TypeOf(expr)))
+			return false
+		}
+		if ident, ok := node.(*ast.Ident); ok {
+			if typeName, ok := info.Uses[ident].(*types.TypeName); ok && typeName.Pkg() != nil {
+				if _, isTypeParam := typeName.Type().(*types.TypeParam); !isTypeParam {
+					g.addImport(typeName.Pkg().Path(), typeName.Pkg().Name())
+				}
+			}
+		}
+		return true
+	})
+}
+
+// isHiddenAlias indicates if expr refers to an unexported alias in another package, or instantiates one.  The generated
+// code can not refer to such an alias, so the aliased type is used instead.
+func (g *Generator) isHiddenAlias(expr ast.Expr, info *types.Info) bool {
+	switch index := expr.(type) {
+	case *ast.IndexExpr:
+		expr = index.X
+	case *ast.IndexListExpr:
+		expr = index.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	typeName, ok := info.Uses[ident].(*types.TypeName)
+	return ok && typeName.IsAlias() && !typeName.Exported() && typeName.Pkg() != nil && typeName.Pkg().Path() != g.selfPackagePath
+}
+
+// qualify returns name qualified with the alias of pkg, unless the mocks are generated into pkg
+func (g *Generator) qualify(pkg *types.Package, name string) string {
+	if pkg.Path() == g.selfPackagePath {
+		return name
+	}
+	return fmt.Sprintf("%s.%s", g.imports[pkg.Path()], name)
+}
+
+// exprToString renders a type expression from the source, qualifying any referenced types with the alias of their
+// package in the generated code
+func (g *Generator) exprToString(expr ast.Expr, info *types.Info) string {
+	if g.isHiddenAlias(expr, info) {
+		return g.typeToString(types.Unalias(info.TypeOf(expr)))
+	}
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if typeName, ok := info.Uses[expr].(*types.TypeName); ok && typeName.Pkg() != nil {
+			if _, isTypeParam := typeName.Type().(*types.TypeParam); !isTypeParam {
+				return g.qualify(typeName.Pkg(), expr.Name)
+			}
+		}
+		return expr.Name
+	case *ast.SelectorExpr:
+		if obj := info.Uses[expr.Sel]; obj != nil && obj.Pkg() != nil {
+			return g.qualify(obj.Pkg(), expr.Sel.Name)
+		}
+	case *ast.StarExpr:
+		return fmt.Sprintf("*%s", g.exprToString(expr.X, info))
+	case *ast.ParenExpr:
+		return fmt.Sprintf("(%s)", g.exprToString(expr.X, info))
+	case *ast.ArrayType:
+		if expr.Len == nil {
+			return fmt.Sprintf("[]%s", g.exprToString(expr.Elt, info))
+		}
+		// The length may refer to a constant in the source package, so the evaluated length is used instead
+		if arrayType, ok := info.TypeOf(expr).(*types.Array); ok {
+			return fmt.Sprintf("[%d]%s", arrayType.Len(), g.exprToString(expr.Elt, info))
+		}
+	case *ast.Ellipsis:
+		return fmt.Sprintf("...%s", g.exprToString(expr.Elt, info))
+	case *ast.MapType:
+		return fmt.Sprintf("map[%s]%s", g.exprToString(expr.Key, info), g.exprToString(expr.Value, info))
+	case *ast.ChanType:
+		switch expr.Dir {
+		case ast.SEND:
+			return fmt.Sprintf("chan<- %s", g.exprToString(expr.Value, info))
+		case ast.RECV:
+			return fmt.Sprintf("<-chan %s", g.exprToString(expr.Value, info))
+		default:
+			return fmt.Sprintf("chan %s", g.exprToString(expr.Value, info))
+		}
+	case *ast.FuncType:
+		return fmt.Sprintf("func%s", g.renderFuncTypeExpr(expr, info))
+	case *ast.InterfaceType:
+		if len(expr.Methods.List) == 0 {
+			return "interface{}"
+		}
+		if iface, ok := info.TypeOf(expr).(*types.Interface); ok {
+			for i := 0; i < iface.NumExplicitMethods(); i++ {
+				g.checkExported(iface.ExplicitMethod(i), "inline interface method")
+			}
+		}
+		elems := make([]string, 0, len(expr.Methods.List))
+		for _, field := range expr.Methods.List {
+			if len(field.Names) == 0 {
+				elems = append(elems, g.exprToString(field.Type, info))
+			} else {
+				elems = append(elems, field.Names[0].Name+g.renderFuncTypeExpr(field.Type.(*ast.FuncType), info))
+			}
+		}
+		return fmt.Sprintf("interface{ %s }", strings.Join(elems, "; "))
+	case *ast.StructType:
+		if len(expr.Fields.List) == 0 {
+			return "struct{}"
+		}
+		if st, ok := info.TypeOf(expr).(*types.Struct); ok {
+			for i := 0; i < st.NumFields(); i++ {
+				g.checkExported(st.Field(i), "anonymous struct field")
+			}
+		}
+		fields := make([]string, 0, len(expr.Fields.List))
+		for _, field := range expr.Fields.List {
+			fieldDef := g.renderFieldExpr(field, info)
+			if field.Tag != nil {
+				fieldDef = fmt.Sprintf("%s %s", fieldDef, field.Tag.Value)
+			}
+			fields = append(fields, fieldDef)
+		}
+		return fmt.Sprintf("struct{ %s }", strings.Join(fields, "; "))
+	case *ast.IndexExpr:
+		return fmt.Sprintf("%s[%s]", g.exprToString(expr.X, info), g.exprToString(expr.Index, info))
+	case *ast.IndexListExpr:
+		indices := make([]string, 0, len(expr.Indices))
+		for _, index := range expr.Indices {
+			indices = append(indices, g.exprToString(index, info))
+		}
+		return fmt.Sprintf("%s[%s]", g.exprToString(expr.X, info), strings.Join(indices, ", "))
+	case *ast.BinaryExpr:
+		if expr.Op == token.OR {
+			return fmt.Sprintf("%s | %s", g.exprToString(expr.X, info), g.exprToString(expr.Y, info))
+		}
+	case *ast.UnaryExpr:
+		if expr.Op == token.TILDE {
+			return fmt.Sprintf("~%s", g.exprToString(expr.X, info))
+		}
+	}
+	return g.typeToString(info.TypeOf(expr))
+}
+
+// renderFuncTypeExpr renders the parameters and results of a function type from the source, without the func keyword
+func (g *Generator) renderFuncTypeExpr(funcType *ast.FuncType, info *types.Info) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	for idx, field := range funcType.Params.List {
+		if idx > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(g.renderFieldExpr(field, info))
+	}
+	sb.WriteString(")")
+
+	if funcType.Results == nil || len(funcType.Results.List) == 0 {
+		return sb.String()
+	}
+	if len(funcType.Results.List) == 1 && len(funcType.Results.List[0].Names) == 0 {
+		sb.WriteByte(' ')
+		sb.WriteString(g.exprToString(funcType.Results.List[0].Type, info))
+		return sb.String()
+	}
+	sb.WriteString(" (")
+	for idx, field := range funcType.Results.List {
+		if idx > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(g.renderFieldExpr(field, info))
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// renderFieldExpr renders the names and type of a field from the source, such as a, b int
+func (g *Generator) renderFieldExpr(field *ast.Field, info *types.Info) string {
+	if len(field.Names) == 0 {
+		return g.exprToString(field.Type, info)
+	}
+	names := make([]string, 0, len(field.Names))
+	for _, name := range field.Names {
+		names = append(names, name.Name)
+	}
+	return fmt.Sprintf("%s %s", strings.Join(names, ", "), g.exprToString(field.Type, info))
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"strings"
+	"unicode"
+)
+
+func MaxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func MinInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// SplitTopLevel splits s on sep, ignoring any sep which is nested inside brackets, braces, or parentheses
+func SplitTopLevel(s string, sep rune) []string {
+	var parts []string
+	depth, start := 0, 0
+	for idx, c := range s {
+		switch c {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:idx])
+				start = idx + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// sanitizeIdentifier removes any characters from s which are not valid in a Go identifier, and lower cases the result
+func sanitizeIdentifier(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+	if s != "" && unicode.IsDigit(rune(s[0])) {
+		return ""
+	}
+	return s
+}
+
+// SnakeCase converts an identifier such as HTTPClient to http_client
+func SnakeCase(s string) string {
+	runes := []rune(s)
+	var sb strings.Builder
+	for idx, r := range runes {
+		if unicode.IsUpper(r) {
+			if idx > 0 && runes[idx-1] != '_' && (!unicode.IsUpper(runes[idx-1]) || (idx+1 < len(runes) && unicode.IsLower(runes[idx+1]))) {
+				sb.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitTopLevel(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		sep  rune
+		want []string
+	}{
+		{name: "empty", s: "", sep: ',', want: []string{""}},
+		{name: "no separator", s: "Store", sep: ',', want: []string{"Store"}},
+		{name: "separators", s: "A,B=MockB,C", sep: ',', want: []string{"A", "B=MockB", "C"}},
+		{name: "empty parts", s: ",A,", sep: ',', want: []string{"", "A", ""}},
+		{name: "brackets", s: "Store[string,int]=IntStore,Getter", sep: ',', want: []string{"Store[string,int]=IntStore", "Getter"}},
+		{name: "nested", s: "Store[map[string]func(a, b int),struct{ A, B int }],X", sep: ',', want: []string{"Store[map[string]func(a, b int),struct{ A, B int }]", "X"}},
+		{name: "other separator", s: "Store[K=V]=Mock", sep: '=', want: []string{"Store[K=V]", "Mock"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitTopLevel(tt.s, tt.sep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitTopLevel(%q, %q) = %q, want %q", tt.s, tt.sep, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "", want: ""},
+		{s: "Store", want: "store"},
+		{s: "store", want: "store"},
+		{s: "ReadCloser", want: "read_closer"},
+		{s: "HTTPClient", want: "http_client"},
+		{s: "IOReader", want: "io_reader"},
+		{s: "UserID", want: "user_id"},
+		{s: "ID", want: "id"},
+		{s: "V2Store", want: "v2_store"},
+		{s: "Read_Closer", want: "read_closer"},
+		{s: "ÉtatStore", want: "état_store"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := SnakeCase(tt.s); got != tt.want {
+				t.Errorf("SnakeCase(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
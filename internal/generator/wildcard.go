@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DefaultNameTemplate names the mocks generated for a wildcard after the interface they implement
+const DefaultNameTemplate = "{{.Name}}"
+
+// nameTemplateData is passed to the naming template of a wildcard, to name each generated mock
+type nameTemplateData struct {
+	Name    string // Name of the interface
+	Package string // Name of the package containing the interface
+}
+
+// IsWildcard indicates if an interface name is either *, to match every exported interface, or /regex/, to match
+// every exported interface with a matching name
+func IsWildcard(ifaceName string) bool {
+	return ifaceName == "*" || (len(ifaceName) >= 2 && strings.HasPrefix(ifaceName, "/") && strings.HasSuffix(ifaceName, "/"))
+}
+
+// ValidateWildcard checks that the regex and naming template of a wildcard are valid
+func ValidateWildcard(ifaceName string, nameTemplate string) error {
+	if ifaceName != "*" {
+		if _, err := regexp.Compile(ifaceName[1 : len(ifaceName)-1]); err != nil {
+			return fmt.Errorf("invalid interface pattern %s: %w", ifaceName, err)
+		}
+	}
+	if _, err := template.New(ifaceName).Parse(nameTemplate); err != nil {
+		return fmt.Errorf("invalid name template for %s: %w", ifaceName, err)
+	}
+	return nil
+}
+
+// ExpandWildcards returns a copy of thingsToGenerate, with every wildcard replaced by the exported interfaces it
+// matches, and every empty name replaced by the name of the interface.  Interfaces which are explicitly listed take
+// precedence over those matched by a wildcard.  Every invalid or unmatched wildcard is reported, and the interfaces
+// matched by the others are still returned.
+func ExpandWildcards(loadedPackages map[string]*packages.Package, thingsToGenerate map[string]map[string]string) (map[string]map[string]string, error) {
+	packageNames := make([]string, 0, len(thingsToGenerate))
+	for packageName := range thingsToGenerate {
+		packageNames = append(packageNames, packageName)
+	}
+	sort.Strings(packageNames) // Errors are reported in a consistent order
+
+	expanded := make(map[string]map[string]string)
+	var errs []error
+	for _, packageName := range packageNames {
+		interfaceFromTo := thingsToGenerate[packageName]
+		expanded[packageName] = make(map[string]string)
+		var wildcards []string
+		for sourceInterfaceName, generatedInterfaceName := range interfaceFromTo {
+			if IsWildcard(sourceInterfaceName) {
+				wildcards = append(wildcards, sourceInterfaceName)
+			} else if generatedInterfaceName == "" {
+				expanded[packageName][sourceInterfaceName] = sourceInterfaceName
+			} else {
+				expanded[packageName][sourceInterfaceName] = generatedInterfaceName
+			}
+		}
+		if len(wildcards) == 0 {
+			continue
+		}
+		sort.Strings(wildcards) // When wildcards overlap, the first in sorted order names the mock
+
+		pkg, ok := loadedPackages[packageName]
+		if !ok {
+			errs = append(errs, fmt.Errorf("package %s is not loaded", packageName))
+			continue
+		}
+
+		for _, sourceInterfaceName := range wildcards {
+			if err := expandWildcard(expanded[packageName], pkg, sourceInterfaceName, interfaceFromTo[sourceInterfaceName]); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return expanded, errors.Join(errs...)
+}
+
+// expandWildcard adds every exported interface in pkg matched by the wildcard sourceInterfaceName to expanded, named
+// by nameTemplate, unless it is already present
+func expandWildcard(expanded map[string]string, pkg *packages.Package, sourceInterfaceName string, nameTemplate string) error {
+	if nameTemplate == "" {
+		nameTemplate = DefaultNameTemplate
+	}
+
+	pattern := regexp.MustCompile("")
+	if sourceInterfaceName != "*" {
+		var err error
+		if pattern, err = regexp.Compile(sourceInterfaceName[1 : len(sourceInterfaceName)-1]); err != nil {
+			return fmt.Errorf("invalid interface pattern %s: %w", sourceInterfaceName, err)
+		}
+	}
+
+	tmpl, err := template.New(sourceInterfaceName).Parse(nameTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid name template for %s: %w", sourceInterfaceName, err)
+	}
+
+	matched := false
+	var errs []error
+	pkgScope := pkg.Types.Scope()
+	for _, name := range pkgScope.Names() {
+		if !token.IsExported(name) || !pattern.MatchString(name) {
+			continue
+		}
+		typeName, ok := pkgScope.Lookup(name).(*types.TypeName)
+		if !ok || typeName.IsAlias() {
+			continue
+		}
+		if iface, ok := typeName.Type().Underlying().(*types.Interface); !ok || !iface.IsMethodSet() {
+			continue // Constraints such as interface{ ~int } can not be mocked
+		}
+		matched = true
+		if _, ok = expanded[name]; ok {
+			continue
+		}
+
+		var sb strings.Builder
+		if err = tmpl.Execute(&sb, nameTemplateData{Name: name, Package: pkg.Name}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to name mock for %s.%s: %w", pkg.PkgPath, name, err))
+			continue
+		}
+		expanded[name] = sb.String()
+	}
+	if !matched {
+		errs = append(errs, fmt.Errorf("%s does not match any interfaces in %s", sourceInterfaceName, pkg.PkgPath))
+	}
+	return errors.Join(errs...)
+}
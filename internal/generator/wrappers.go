@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+type IfaceWrapper struct {
+	Named             *types.Named
+	LongestMethodName int
+	Methods           []*FuncWrapper
+	TypeParams        []*types.TypeParam
+	TypeParamExprs    []ast.Expr // Constraints as written in the source, nil if unavailable
+	typeParamInfo     *types.Info
+}
+
+type FuncWrapper struct {
+	Name        string
+	Pos         token.Pos
+	Variadic    bool
+	Params      []*types.Var
+	Results     []*types.Var
+	ParamExprs  []ast.Expr // Parameter types as written in the source, nil if unavailable
+	ResultExprs []ast.Expr // Result types as written in the source, nil if unavailable
+	info        *types.Info
+	paramNames  []string
+	fieldNames  []string
+}
+
+func NewInterface(iface *types.Interface, typeParams *types.TypeParamList) *IfaceWrapper {
+	iw := &IfaceWrapper{}
+	for i := 0; i < typeParams.Len(); i++ {
+		iw.TypeParams = append(iw.TypeParams, typeParams.At(i))
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		fn := NewFunc(iface.Method(i).Name(), iface.Method(i).Type().(*types.Signature))
+		fn.Pos = iface.Method(i).Pos()
+		iw.Methods = append(iw.Methods, fn)
+		iw.LongestMethodName = MaxInt(iw.LongestMethodName, len(fn.Name))
+	}
+	sort.Slice(iw.Methods, func(i, j int) bool {
+		return iw.Methods[i].Name < iw.Methods[j].Name
+	})
+	return iw
+}
+
+// HasMethod indicates if the interface has a method called name
+func (iw *IfaceWrapper) HasMethod(name string) bool {
+	for _, methodDef := range iw.Methods {
+		if methodDef.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func NewFunc(name string, sig *types.Signature) *FuncWrapper {
+	params := sig.Params()
+	results := sig.Results()
+	fw := &FuncWrapper{
+		Name:     name,
+		Params:   make([]*types.Var, 0, params.Len()),
+		Results:  make([]*types.Var, 0, results.Len()),
+		Variadic: sig.Variadic(),
+	}
+	for i := 0; i < params.Len(); i++ {
+		fw.Params = append(fw.Params, params.At(i))
+	}
+
+	for i := 0; i < results.Len(); i++ {
+		fw.Results = append(fw.Results, results.At(i))
+	}
+	fw.paramNames = fw.uniqueParamNames()
+	fw.fieldNames = fw.uniqueFieldNames()
+	return fw
+}
+
+// reservedParamNames are the receiver and local variables of the generated methods, so parameters with these names
+// are renamed to avoid shadowing them
+var reservedParamNames = NewSetString([]string{"m", "e", "call", "expectations", "exhausted"})
+
+// uniqueParamNames names every parameter, keeping the name from the source unless it is blank or clashes with a
+// reserved name or the p0..pN names of the results.  Renamed parameters are given a numeric suffix, which is increased
+// until the name is not used by any other parameter.
+func (fw *FuncWrapper) uniqueParamNames() []string {
+	used := make(SetString)
+	for name := range reservedParamNames {
+		used.Add(name)
+	}
+	for idx := range fw.Results {
+		used.Add(fmt.Sprintf("p%d", idx))
+	}
+
+	names := make([]string, len(fw.Params))
+	for idx, param := range fw.Params {
+		if name := param.Name(); name != "" && name != "_" {
+			if _, clash := used[name]; !clash {
+				names[idx] = name
+			}
+		}
+	}
+	for _, name := range names {
+		if name != "" {
+			used.Add(name)
+		}
+	}
+
+	for idx, param := range fw.Params {
+		if names[idx] != "" {
+			continue
+		}
+		base := param.Name()
+		if base == "" || base == "_" {
+			base = "a"
+		}
+		name := fmt.Sprintf("%s%d", base, idx)
+		for suffix := idx + 1; ; suffix++ {
+			if _, clash := used[name]; !clash {
+				break
+			}
+			name = fmt.Sprintf("%s%d", base, suffix)
+		}
+		names[idx] = name
+		used.Add(name)
+	}
+	return names
+}
+
+// ParamName returns the name of the parameter at idx, or a generated name if it is unnamed, blank, or clashes with
+// another name in the generated method
+func (fw *FuncWrapper) ParamName(idx int) string {
+	return fw.paramNames[idx]
+}
+
+// uniqueFieldNames names the fields of the call struct for every parameter, by exporting the name of the parameter.
+// Parameters whose names are already exported keep them, and any other parameter whose field would clash, such as a
+// with A, is given a numeric suffix.
+func (fw *FuncWrapper) uniqueFieldNames() []string {
+	names := make([]string, len(fw.Params))
+	used := make(SetString)
+	for idx, param := range fw.Params {
+		if name := param.Name(); token.IsExported(name) {
+			names[idx] = name
+			used.Add(name)
+		}
+	}
+
+	for idx, param := range fw.Params {
+		if names[idx] != "" {
+			continue
+		}
+		base := param.Name()
+		if base == "" || base == "_" {
+			base = fw.ParamName(idx)
+		}
+		if r, size := utf8.DecodeRuneInString(base); unicode.IsUpper(unicode.ToUpper(r)) {
+			base = string(unicode.ToUpper(r)) + base[size:]
+		} else {
+			base = "P" + base // The name starts with a letter which has no upper case, so can not be exported
+		}
+
+		name := base
+		for suffix := idx; ; suffix++ {
+			if _, clash := used[name]; !clash {
+				break
+			}
+			name = fmt.Sprintf("%s%d", base, suffix)
+		}
+		names[idx] = name
+		used.Add(name)
+	}
+	return names
+}
+
+// ParamFieldName returns the exported struct field name used to record the parameter at idx
+func (fw *FuncWrapper) ParamFieldName(idx int) string {
+	return fw.fieldNames[idx]
+}
@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"go/token"
+	"go/types"
+	"reflect"
+	"testing"
+)
+
+// newTestFunc returns a FuncWrapper for a function with int parameters with the given names, and results results
+func newTestFunc(params []string, results int) *FuncWrapper {
+	paramVars := make([]*types.Var, 0, len(params))
+	for _, name := range params {
+		paramVars = append(paramVars, types.NewParam(token.NoPos, nil, name, types.Typ[types.Int]))
+	}
+	resultVars := make([]*types.Var, 0, results)
+	for i := 0; i < results; i++ {
+		resultVars = append(resultVars, types.NewParam(token.NoPos, nil, "", types.Typ[types.Int]))
+	}
+	sig := types.NewSignatureType(nil, nil, nil, types.NewTuple(paramVars...), types.NewTuple(resultVars...), false)
+	return NewFunc("F", sig)
+}
+
+func TestParamName(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  []string
+		results int
+		want    []string
+	}{
+		{name: "named", params: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "unnamed", params: []string{"", ""}, want: []string{"a0", "a1"}},
+		{name: "blank", params: []string{"_", "x"}, want: []string{"a0", "x"}},
+		{name: "blank next to generated name", params: []string{"a1", "_"}, want: []string{"a1", "a2"}},
+		{name: "reserved", params: []string{"m", "e", "call"}, want: []string{"m0", "e1", "call2"}},
+		{name: "reserved next to renamed", params: []string{"m", "m0"}, want: []string{"m1", "m0"}},
+		{name: "result name", params: []string{"p0"}, results: 1, want: []string{"p00"}},
+		{name: "result name unused", params: []string{"p1"}, results: 1, want: []string{"p1"}},
+		{name: "chain", params: []string{"e", "e0", "e1"}, want: []string{"e2", "e0", "e1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := newTestFunc(tt.params, tt.results)
+			got := make([]string, 0, len(tt.params))
+			for idx := range tt.params {
+				got = append(got, fn.ParamName(idx))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParamName() for %q = %q, want %q", tt.params, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParamFieldName(t *testing.T) {
+	tests := []struct {
+		name   string
+		params []string
+		want   []string
+	}{
+		{name: "named", params: []string{"ctx", "key"}, want: []string{"Ctx", "Key"}},
+		{name: "unnamed", params: []string{"", "_"}, want: []string{"A0", "A1"}},
+		{name: "case clash", params: []string{"a", "A"}, want: []string{"A0", "A"}},
+		{name: "case clash after", params: []string{"Ctx", "ctx"}, want: []string{"Ctx", "Ctx1"}},
+		{name: "suffix clash", params: []string{"a", "A", "A0"}, want: []string{"A1", "A", "A0"}},
+		{name: "renamed", params: []string{"m", "e"}, want: []string{"M", "E"}},
+		{name: "no upper case", params: []string{"名"}, want: []string{"P名"}},
+		{name: "non-ASCII", params: []string{"ñ"}, want: []string{"Ñ"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := newTestFunc(tt.params, 0)
+			got := make([]string, 0, len(tt.params))
+			for idx := range tt.params {
+				got = append(got, fn.ParamFieldName(idx))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParamFieldName() for %q = %q, want %q", tt.params, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package mockgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/squizzling/mockgen/internal/generator"
+)
+
+const (
+	LayoutFile      = "file"      // Every mock is written to a single file
+	LayoutPackage   = "package"   // The mocks for each source package are written to their own file
+	LayoutInterface = "interface" // Each mock is written to its own file
+)
+
+const (
+	defaultPackageFileTemplate   = "mock_{{.Package | snake}}.go"
+	defaultInterfaceFileTemplate = "mock_{{.Name | snake}}.go"
+)
+
+// fileTemplateData is passed to the FileTemplate of a Config, to name each generated file.  Iface and Name are empty
+// for LayoutPackage.  Mocks which are given the same file name are written to the same file.
+type fileTemplateData struct {
+	Iface   string // Name of the interface, without any type arguments
+	Name    string // Name of the mock, without the Mock prefix
+	Package string // Name of the package containing the interface
+}
+
+var fileTemplateFuncs = template.FuncMap{
+	"snake": generator.SnakeCase,
+	"lower": strings.ToLower,
+}
+
+// fileTemplate parses the FileTemplate of c, or the default template for its layout if it is empty
+func (c *Config) fileTemplate() (*template.Template, error) {
+	fileTemplate := c.FileTemplate
+	if fileTemplate == "" {
+		if c.Layout == LayoutPackage {
+			fileTemplate = defaultPackageFileTemplate
+		} else {
+			fileTemplate = defaultInterfaceFileTemplate
+		}
+	}
+	tmpl, err := template.New("file").Funcs(fileTemplateFuncs).Parse(fileTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid file template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// GenerateFiles returns the formatted source of every file generated for the layout of cfg, keyed by path.  The
+// packages containing the interfaces are loaded unless pkgs is given, which allows the packages for several layouts to
+// be loaded together with LoadPackages.  Files which were generated successfully are returned even if others failed.
+func GenerateFiles(ctx context.Context, cfg Config, pkgs ...*packages.Package) (map[string][]byte, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		var err error
+		if pkgs, err = LoadPackages(ctx, cfg.Dir, cfg.Packages); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := cfg.splitFiles(pkgs)
+	if err != nil {
+		return nil, err
+	}
+
+	generated := make(map[string][]byte)
+	var errs []error
+	for _, file := range files {
+		data, err := generate(file, pkgs)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		generated[file.File] = data
+	}
+	return generated, errors.Join(errs...)
+}
+
+// splitFiles returns a Config with LayoutFile for every file generated for the layout of c, sorted by path
+func (c *Config) splitFiles(pkgs []*packages.Package) ([]Config, error) {
+	if c.Layout == "" || c.Layout == LayoutFile {
+		return []Config{*c}, nil
+	}
+
+	tmpl, err := c.fileTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	loadedPackages := make(map[string]*packages.Package)
+	for _, pkg := range pkgs {
+		loadedPackages[pkg.PkgPath] = pkg
+	}
+
+	thingsToGenerate := c.Packages
+	if c.Layout == LayoutInterface {
+		// Wildcards are expanded here, so each interface they match can be given its own file
+		if thingsToGenerate, err = generator.ExpandWildcards(loadedPackages, thingsToGenerate); err != nil {
+			return nil, err
+		}
+	}
+
+	files := make(map[string]*Config)
+	addFile := func(data fileTemplateData, pkgPath string, ifaceName string, structName string) error {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return fmt.Errorf("failed to name file for %s: %w", pkgPath, err)
+		}
+		if sb.Len() == 0 || strings.ContainsRune(sb.String(), filepath.Separator) {
+			return fmt.Errorf("invalid file name %q for %s", sb.String(), pkgPath)
+		}
+		path := filepath.Join(c.OutputDir, sb.String())
+
+		file, ok := files[path]
+		if !ok {
+			file = &Config{}
+			*file = *c
+			file.File = path
+			file.Layout = LayoutFile
+			file.OutputDir = ""
+			file.FileTemplate = ""
+			file.Packages = make(map[string]map[string]string)
+			files[path] = file
+		}
+		if _, ok = file.Packages[pkgPath]; !ok {
+			file.Packages[pkgPath] = make(map[string]string)
+		}
+		file.Packages[pkgPath][ifaceName] = structName
+		return nil
+	}
+
+	for pkgPath, interfaces := range thingsToGenerate {
+		pkg, ok := loadedPackages[pkgPath]
+		if !ok {
+			return nil, fmt.Errorf("package %s is not loaded", pkgPath)
+		}
+		for ifaceName, structName := range interfaces {
+			data := fileTemplateData{Package: pkg.Name}
+			if c.Layout == LayoutInterface {
+				data.Iface, _, _ = strings.Cut(ifaceName, "[")
+				data.Name = structName
+			}
+			if err = addFile(data, pkgPath, ifaceName, structName); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	split := make([]Config, 0, len(paths))
+	for _, path := range paths {
+		split = append(split, *files[path])
+	}
+	return split, nil
+}
@@ -0,0 +1,189 @@
+// Package mockgen generates mocks for Go interfaces.  Each mock is a struct with a function field for every method
+// of the interface, and records the calls made to it.
+package mockgen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+
+	"github.com/squizzling/mockgen/internal/generator"
+)
+
+// The strictness of a mock determines how it handles a call with no Fn field, expectation, or Impl to handle it
+const (
+	StrictnessStrict       = generator.StrictnessStrict       // Fail the test
+	StrictnessLooseZero    = generator.StrictnessLooseZero    // Return zero values
+	StrictnessLooseWithLog = generator.StrictnessLooseWithLog // Return zero values, and log the call with TB.Logf
+)
+
+// The backend determines how a mock fails the test
+const (
+	BackendTestify = generator.BackendTestify // Use github.com/stretchr/testify
+	BackendTesting = generator.BackendTesting // Use only testing.TB, so the generated code has no dependencies
+)
+
+// Config describes a single generated file
+type Config struct {
+	// Dir is the directory packages are loaded from, the current directory is used if it is empty
+	Dir string `yaml:"-"`
+
+	// File is the path the generated code will be written to.  It is not written by Generate, but is used to detect
+	// when the mocks are generated into the same package as the interfaces they implement.  It may be empty if the
+	// code is not written to a file.
+	File string `yaml:"file"`
+
+	// Layout selects how the mocks are split between files, and is one of LayoutFile (the default), LayoutPackage, or
+	// LayoutInterface.  Every layout other than LayoutFile writes to OutputDir instead of File, naming each file with
+	// FileTemplate.
+	Layout       string `yaml:"layout"`
+	OutputDir    string `yaml:"output_dir"`
+	FileTemplate string `yaml:"file_template"`
+
+	Package               string `yaml:"package"`
+	Module                string `yaml:"module"`
+	Expectations          bool   `yaml:"expectations"`
+	NoInterfaceAssertions bool   `yaml:"no_interface_assertions"`
+	Spy                   bool   `yaml:"spy"`
+	Strictness            string `yaml:"strictness"`
+	Backend               string `yaml:"backend"`
+	Fatal                 bool   `yaml:"fatal"`
+
+	// Packages maps an import path to the interfaces to mock from it, and the name of the generated mock for each
+	// interface.  An empty name will use the name of the interface.  The interface may be * or /regex/ to match
+	// every exported interface, in which case the name is a template such as Fake{{.Name}}.
+	Packages map[string]map[string]string `yaml:"packages"`
+}
+
+// Validate checks that the Config describes a file which can be generated
+func (c *Config) Validate() error {
+	switch c.Layout {
+	case "", LayoutFile:
+	case LayoutPackage, LayoutInterface:
+		if c.File != "" {
+			return fmt.Errorf("file can not be used with the %s layout", c.Layout)
+		}
+		if c.OutputDir == "" {
+			return fmt.Errorf("output_dir is required for the %s layout", c.Layout)
+		}
+		if _, err := c.fileTemplate(); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown layout %s", c.Layout)
+	}
+	switch c.Backend {
+	case "", BackendTestify, BackendTesting:
+	default:
+		return fmt.Errorf("unknown backend %s", c.Backend)
+	}
+	switch c.Strictness {
+	case "", StrictnessStrict, StrictnessLooseZero, StrictnessLooseWithLog:
+	default:
+		return fmt.Errorf("unknown strictness %s", c.Strictness)
+	}
+	if c.Package == "" {
+		return errors.New("package is required")
+	}
+	if len(c.Packages) == 0 {
+		return errors.New("no packages defined")
+	}
+	for pkgPath, interfaces := range c.Packages {
+		if len(interfaces) == 0 {
+			return fmt.Errorf("no interfaces defined for %s", pkgPath)
+		}
+		for ifaceName, structName := range interfaces {
+			if generator.IsWildcard(ifaceName) {
+				if structName == "" {
+					structName = generator.DefaultNameTemplate
+				}
+				if err := generator.ValidateWildcard(ifaceName, structName); err != nil {
+					return err
+				}
+			} else if structName == "" && strings.Contains(ifaceName, "[") {
+				return fmt.Errorf("instantiated interface %s requires a struct name", ifaceName)
+			}
+		}
+	}
+	return nil
+}
+
+// Generate returns the formatted source of the mocks described by cfg.  The packages containing the interfaces are
+// loaded unless pkgs is given, which allows the packages for several files to be loaded together with LoadPackages.
+func Generate(ctx context.Context, cfg Config, pkgs ...*packages.Package) ([]byte, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Layout != "" && cfg.Layout != LayoutFile {
+		return nil, fmt.Errorf("the %s layout generates several files, use GenerateFiles", cfg.Layout)
+	}
+	if len(pkgs) == 0 {
+		var err error
+		if pkgs, err = LoadPackages(ctx, cfg.Dir, cfg.Packages); err != nil {
+			return nil, err
+		}
+	}
+	return generate(cfg, pkgs)
+}
+
+// generate returns the formatted source of the mocks described by cfg, which must have LayoutFile
+func generate(cfg Config, pkgs []*packages.Package) ([]byte, error) {
+	outputDir := filepath.Dir(cfg.File)
+	if !filepath.IsAbs(outputDir) {
+		outputDir = filepath.Join(cfg.Dir, outputDir)
+	}
+	g, err := generator.NewGenerator(cfg.Package, cfg.Module, pkgs, cfg.Packages, generator.GeneratorOptions{
+		Expectations:          cfg.Expectations,
+		NoInterfaceAssertions: cfg.NoInterfaceAssertions,
+		Spy:                   cfg.Spy,
+		Strictness:            cfg.Strictness,
+		Backend:               cfg.Backend,
+		Fatal:                 cfg.Fatal,
+		OutputDir:             outputDir,
+	})
+	if err != nil {
+		return nil, err
+	}
+	src, err := g.Generate()
+	if err != nil {
+		return nil, err
+	}
+	data, err := generator.FormatSource(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code for %s: %w", cfg.File, err)
+	}
+	return []byte(data), nil
+}
+
+// LoadPackages loads the packages containing the interfaces to generate, from dir.  Every error reported while
+// loading the packages is returned, rather than only the first.
+func LoadPackages(ctx context.Context, dir string, packagesToGenerate map[string]map[string]string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedFiles | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule | packages.NeedImports,
+		Context: ctx,
+		Dir:     dir,
+		Tests:   false,
+	}
+
+	pkgNames := make([]string, 0, len(packagesToGenerate))
+	for pkgName, _ := range packagesToGenerate {
+		pkgNames = append(pkgNames, pkgName)
+	}
+
+	pkgs, err := packages.Load(cfg, pkgNames...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load packages: %w", err)
+	}
+
+	var errs []error
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			errs = append(errs, fmt.Errorf("error loading package %s: %w", pkg.PkgPath, pkgErr))
+		}
+	}
+	return pkgs, errors.Join(errs...)
+}
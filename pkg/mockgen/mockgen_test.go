@@ -0,0 +1,88 @@
+package mockgen
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestGenerateCompiles generates mocks for testdata/fixture with several configurations, and checks the result with
+// go vet, and the tests in testdata/fixture which use the generated mocks.  The testing backend is used throughout, so
+// the fixture module has no dependencies.
+func TestGenerateCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping go vet of generated code in short mode")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not available")
+	}
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "go.mod"), []byte("module example.com/fixture\n\ngo 1.21\n"))
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "fixture", "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fixture := range fixtures {
+		data, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, "fixture", filepath.Base(fixture)), data)
+	}
+
+	interfaces := map[string]map[string]string{
+		"example.com/fixture/fixture": {
+			"Store":             "",
+			"Edge":              "",
+			"Cache[string,int]": "StringCache",
+		},
+	}
+	configs := []Config{
+		{File: "calls/mocks.go", Package: "calls"},
+		{File: "expect/mocks.go", Package: "expect", Expectations: true, Strictness: StrictnessLooseWithLog},
+		{File: "spy/mocks.go", Package: "spy", Expectations: true, Spy: true, Fatal: true},
+		{File: "fixture/mock_fixture.go", Package: "fixture", Expectations: true},
+		{Layout: LayoutInterface, OutputDir: "layout", Package: "layout", Spy: true},
+	}
+
+	ctx := context.Background()
+	pkgs, err := LoadPackages(ctx, dir, interfaces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cfg := range configs {
+		cfg.Dir = dir
+		cfg.Backend = BackendTesting
+		cfg.Packages = interfaces
+		files, err := GenerateFiles(ctx, cfg, pkgs...)
+		if err != nil {
+			t.Fatalf("GenerateFiles() for %s%s: %v", cfg.File, cfg.OutputDir, err)
+		}
+		for path, data := range files {
+			writeFile(t, filepath.Join(dir, path), data)
+		}
+	}
+
+	for _, args := range [][]string{{"vet", "./..."}, {"test", "./..."}} {
+		cmd := exec.Command(goBin, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=-mod=mod")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("go %s failed: %v\n%s", args[0], err, out)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,23 @@
+// Package fixture contains the interfaces which are mocked by TestGenerateCompiles
+package fixture
+
+import "context"
+
+type Store interface {
+	Get(ctx context.Context, key string) (string, error)
+	Put(key string, value []byte) error
+}
+
+// Edge has parameters which clash with the names used by the generated code, or with each other once exported
+type Edge interface {
+	D(m, m0 int)
+	X(p0 int) error
+	Y(_, a1 string)
+	Z(e, e0 int, call string, p1 bool) (int, bool)
+	Case(a int, A int)
+	Log(format string, args ...any)
+}
+
+type Cache[K comparable, V any] interface {
+	Load(key K) (V, bool)
+}
@@ -0,0 +1,46 @@
+package fixture_test
+
+import (
+	"context"
+	"testing"
+
+	"example.com/fixture/expect"
+	"example.com/fixture/fixture"
+	"example.com/fixture/spy"
+)
+
+type store map[string]string
+
+func (s store) Get(ctx context.Context, key string) (string, error) { return s[key], nil }
+func (s store) Put(key string, value []byte) error                  { s[key] = string(value); return nil }
+
+func TestSamePackage(t *testing.T) {
+	m := fixture.NewMockEdge(t)
+	m.FnCase = func(a int, A int) {}
+	m.Case(1, 2)
+	if calls := m.CaseCalls(); len(calls) != 1 || calls[0].A0 != 1 || calls[0].A != 2 {
+		t.Fatalf("unexpected calls %#v", calls)
+	}
+}
+
+func TestExpectations(t *testing.T) {
+	m := expect.NewMockStore(t)
+	m.ExpectGet(nil, "k").Match(func(ctx context.Context, key string) bool {
+		// Matchers are called without the lock, so they may use the mock
+		return m.GetCallCount() >= 0 && key == "k"
+	}).Return("v", nil)
+	if v, err := m.Get(context.Background(), "k"); v != "v" || err != nil {
+		t.Fatalf("Get() = %q, %v", v, err)
+	}
+	m.AssertExpectations()
+}
+
+func TestSpy(t *testing.T) {
+	m := spy.NewMockStore(t, func(m *spy.MockStore) { m.Impl = store{} })
+	if err := m.Put("k", []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Get(context.Background(), "k"); v != "v" || m.PutCallCount() != 1 {
+		t.Fatalf("Get() = %q after %d calls to Put", v, m.PutCallCount())
+	}
+}